@@ -0,0 +1,16 @@
+resource "testlagger_lag" "test" {
+  count        = 20
+  create_delay = 1000
+  input        = "hello-${count.index}"
+}
+
+data "testlagger_concurrency" "test" {
+  depends_on = [testlagger_lag.test]
+}
+
+check "parallelism" {
+  assert {
+    condition     = data.testlagger_concurrency.test.peak_in_flight_by_operation["resource_create"] <= 10
+    error_message = "More than 10 resources were created at once."
+  }
+}
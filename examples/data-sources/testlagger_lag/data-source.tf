@@ -1,4 +1,5 @@
 data "testlagger_lag" "test" {
-  read_delay = 1000
-  input      = "hello"
+  validate_delay = 1000
+  read_delay     = 1000
+  input          = "hello"
 }
\ No newline at end of file
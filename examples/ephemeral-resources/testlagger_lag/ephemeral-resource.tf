@@ -0,0 +1,7 @@
+ephemeral "testlagger_lag" "test" {
+  open_delay  = 1000
+  renew_delay = 1000
+  close_delay = 1000
+  renew_at    = 5000
+  input       = "hello"
+}
\ No newline at end of file
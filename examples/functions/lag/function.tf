@@ -1,3 +1,7 @@
 output "lag_echo" {
   value = provider::testlagger::lag(1000, "hello")
-}
\ No newline at end of file
+}
+
+output "lag_echo_jitter" {
+  value = provider::testlagger::lag({ distribution = "normal", mean = 1000, stddev = 200, seed = 42 }, "hello")
+}
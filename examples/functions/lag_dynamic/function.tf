@@ -0,0 +1,3 @@
+output "lag_dynamic_echo" {
+  value = provider::testlagger::lag_dynamic(1000, { name = "hello", tags = ["a", "b"] })
+}
@@ -1,7 +1,13 @@
 # Configuration-based authentication
 provider "testlagger" {
-  client_initialize_delay     = 1000
-  datasource_configure_delay  = 1000
-  resource_configure_delay    = 1000
-  resource_import_state_delay = 1000
+  validate_delay               = 1000
+  client_initialize_delay      = 1000
+  datasource_configure_delay   = 1000
+  resource_configure_delay     = 1000
+  resource_import_state_delay  = 1000
+  resource_plan_delay          = 1000
+  resource_upgrade_state_delay = 1000
+  resource_move_state_delay    = 1000
+  resource_identity_delay      = 1000
+  ephemeral_configure_delay    = 1000
 }
\ No newline at end of file
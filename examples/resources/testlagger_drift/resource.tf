@@ -0,0 +1,13 @@
+# Requires the provider remote_store_path, for example
+# provider "testlagger" {
+#   remote_store_path = "${path.root}/.remote"
+# }
+resource "testlagger_lag" "test" {
+  input = "hello"
+}
+
+# Change the remote object, so the next refresh of testlagger_lag.test finds drift
+resource "testlagger_drift" "test" {
+  target_id = testlagger_lag.test.id
+  input     = "drifted"
+}
\ No newline at end of file
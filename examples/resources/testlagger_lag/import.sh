@@ -0,0 +1,3 @@
+# The import ID is the input, optionally followed by the namespace and the
+# delays in milliseconds to restore.
+terraform import testlagger_lag.example "hello;namespace=team;create=100;read=50"
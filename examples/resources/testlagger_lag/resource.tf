@@ -1,7 +1,9 @@
 resource "testlagger_lag" "test" {
-  create_delay = 1000
-  read_delay   = 1000
-  update_delay = 1000
-  delete_delay = 1000
-  input        = "hello"
+  validate_delay = 1000
+  plan_delay     = 1000
+  create_delay   = 1000
+  read_delay     = 1000
+  update_delay   = 1000
+  delete_delay   = 1000
+  input          = "hello"
 }
\ No newline at end of file
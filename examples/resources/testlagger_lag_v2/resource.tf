@@ -0,0 +1,13 @@
+# Move an existing testlagger_lag, delayed by the provider resource_move_state_delay
+moved {
+  from = testlagger_lag.test
+  to   = testlagger_lag_v2.test
+}
+
+resource "testlagger_lag_v2" "test" {
+  create_delay = 1000
+  read_delay   = 1000
+  update_delay = 1000
+  delete_delay = 1000
+  input        = "hello"
+}
\ No newline at end of file
@@ -0,0 +1,203 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package daemon holds simulated API state shared by several provider
+// processes, served over a Unix socket to providers that set an endpoint.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// State is the simulated API state shared by every provider process using
+// the daemon: the number of calls made to each lag point, the remote objects
+// of lag resources and the rate limit.
+type State struct {
+	mutex   sync.Mutex
+	calls   map[string]int64
+	objects map[string]provider.RemoteObject
+
+	bucket provider.TokenBucket
+}
+
+// NewState returns empty state.
+func NewState() *State {
+	return &State{
+		calls:   map[string]int64{},
+		objects: map[string]provider.RemoteObject{},
+	}
+}
+
+// Handler returns the HTTP handler serving the state.
+func (s *State) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, provider.DaemonHealth{Pid: os.Getpid()})
+	})
+
+	mux.HandleFunc("POST /v1/calls/{lagPoint}", func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.calls[r.PathValue("lagPoint")]++
+		count := s.calls[r.PathValue("lagPoint")]
+		s.mutex.Unlock()
+
+		writeJSON(w, provider.DaemonCallCount{Count: count})
+	})
+
+	mux.HandleFunc("POST /v1/reserve", func(w http.ResponseWriter, r *http.Request) {
+		var req provider.DaemonReserveRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if req.RequestsPerSecond <= 0 || req.Burst < 1 {
+			http.Error(w, fmt.Sprintf("requests per second must be positive and burst at least 1, got %g and %d", req.RequestsPerSecond, req.Burst), http.StatusBadRequest)
+			return
+		}
+
+		wait, ok := s.bucket.Reserve(time.Now(), req.RequestsPerSecond, req.Burst, req.Queue)
+
+		writeJSON(w, provider.DaemonReservation{WaitNs: int64(wait), Ok: ok})
+	})
+
+	// Objects are matched by the rest of the path, so that the empty id of a
+	// resource with an empty input is a valid one rather than no route
+	mux.HandleFunc("GET /v1/objects/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		object, ok := s.objects[r.PathValue("id")]
+		s.mutex.Unlock()
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(w, object)
+	})
+
+	mux.HandleFunc("PUT /v1/objects/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		var object provider.RemoteObject
+
+		if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if object.Id != r.PathValue("id") {
+			http.Error(w, fmt.Sprintf("object id %q does not match %q", object.Id, r.PathValue("id")), http.StatusBadRequest)
+			return
+		}
+
+		s.mutex.Lock()
+		s.objects[object.Id] = object
+		s.mutex.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("DELETE /v1/objects/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		delete(s.objects, r.PathValue("id"))
+		s.mutex.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// Listen listens on the Unix socket at the given path, replacing a socket
+// left behind by a daemon that did not shut down cleanly.
+func Listen(socket string) (net.Listener, error) {
+	if _, err := provider.NewDaemonClient(socket).Health(context.Background()); err == nil {
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return net.Listen("unix", socket)
+}
+
+// Serve serves the state on the listener until the context is done.
+func (s *State) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Run implements the daemon subcommand.
+func Run(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: terraform-provider-testlagger daemon -socket <path>\n\n")
+		fmt.Fprintf(stderr, "Holds the call counts, remote objects and rate limit shared by every provider whose endpoint is the socket, until interrupted.\n\n")
+		flags.PrintDefaults()
+	}
+
+	var socket string
+
+	flags.StringVar(&socket, "socket", "", "path of the Unix socket to listen on")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	if socket == "" {
+		flags.Usage()
+		return errors.New("-socket is required")
+	}
+
+	listener, err := Listen(socket)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(stdout, "Listening on %s, set the provider endpoint to this path\n", socket)
+
+	return NewState().Serve(ctx, listener)
+}
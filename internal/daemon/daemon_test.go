@@ -0,0 +1,269 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// testDaemon serves new state on a socket in a temporary directory until the
+// test ends, and returns the path of the socket.
+func testDaemon(t *testing.T) string {
+	// Unix socket paths are limited to around 100 bytes, which a test's
+	// temporary directory can exceed
+	dir, err := os.MkdirTemp("", "testlagger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "daemon.sock")
+
+	listener, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- NewState().Serve(ctx, listener)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+
+	return socket
+}
+
+func TestDaemon_CountCall(t *testing.T) {
+	socket := testDaemon(t)
+
+	// Clients in separate processes share one count
+	clients := []*provider.DaemonClient{provider.NewDaemonClient(socket), provider.NewDaemonClient(socket)}
+
+	var wait sync.WaitGroup
+
+	for _, client := range clients {
+		wait.Add(1)
+
+		go func() {
+			defer wait.Done()
+
+			for range 10 {
+				if _, err := client.CountCall("resource/create"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+
+	wait.Wait()
+
+	count, err := clients[0].CountCall("resource/create")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 21 {
+		t.Errorf("expected the 21st call, got %d", count)
+	}
+
+	count, err = clients[1].CountCall("resource/read")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 {
+		t.Errorf("expected each lag point to be counted separately, got %d", count)
+	}
+}
+
+func TestDaemon_Objects(t *testing.T) {
+	socket := testDaemon(t)
+
+	writer := provider.NewDaemonClient(socket)
+	reader := provider.NewDaemonClient(socket)
+
+	namespace := "team"
+	object := provider.RemoteObject{Id: "a/b", Namespace: &namespace, Input: "a/b", Output: "a/b"}
+
+	if err := writer.Put(object); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := reader.Get("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil || got.Id != object.Id || got.Input != object.Input || got.Output != object.Output || got.Namespace == nil || *got.Namespace != namespace {
+		t.Errorf("expected %+v, got %+v", object, got)
+	}
+
+	if err := writer.Delete("a/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := reader.Get("a/b"); err != nil || got != nil {
+		t.Errorf("expected a deleted object to be missing, got %+v, %v", got, err)
+	}
+}
+
+func TestDaemon_EmptyObjectId(t *testing.T) {
+	client := provider.NewDaemonClient(testDaemon(t))
+
+	object := provider.RemoteObject{Id: "", Input: "", Output: ""}
+
+	if err := client.Put(object); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := client.Get(""); err != nil || got == nil {
+		t.Fatalf("expected the object with an empty id, got %+v, %v", got, err)
+	}
+
+	if err := client.Delete(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := client.Get(""); err != nil || got != nil {
+		t.Errorf("expected a deleted object to be missing, got %+v, %v", got, err)
+	}
+}
+
+func TestDaemonClient_NotFound(t *testing.T) {
+	dir, err := os.MkdirTemp("", "testlagger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "daemon.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A server without the object routes, answering not found to everything
+	server := &http.Server{Handler: http.NotFoundHandler()}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	client := provider.NewDaemonClient(socket)
+
+	if got, err := client.Get("one"); err != nil || got != nil {
+		t.Errorf("expected a missing object from Get, got %+v, %v", got, err)
+	}
+
+	if err := client.Put(provider.RemoteObject{Id: "one"}); err == nil {
+		t.Error("expected Put to fail when not found")
+	}
+
+	if err := client.Delete("one"); err == nil {
+		t.Error("expected Delete to fail when not found")
+	}
+}
+
+func TestDaemon_Reserve(t *testing.T) {
+	socket := testDaemon(t)
+
+	// Clients in separate processes share one rate limit
+	first := provider.NewDaemonClient(socket)
+	second := provider.NewDaemonClient(socket)
+
+	if wait, ok, err := first.Reserve(1, 1, false); err != nil || !ok || wait != 0 {
+		t.Errorf("expected the first call to be allowed, got %s, %t, %v", wait, ok, err)
+	}
+
+	if wait, ok, err := second.Reserve(1, 1, false); err != nil || ok || wait <= 0 {
+		t.Errorf("expected the second call to be refused, got %s, %t, %v", wait, ok, err)
+	}
+
+	if wait, ok, err := second.Reserve(1, 1, true); err != nil || !ok || wait <= 0 {
+		t.Errorf("expected the queued call to wait, got %s, %t, %v", wait, ok, err)
+	}
+
+	if _, _, err := first.Reserve(0, 1, true); err == nil {
+		t.Error("expected an error reserving without a rate")
+	}
+}
+
+func TestListen_InUse(t *testing.T) {
+	socket := testDaemon(t)
+
+	if _, err := Listen(socket); err == nil {
+		t.Error("expected an error listening on a socket in use")
+	}
+}
+
+// testConfigureProvider configures a provider with the given endpoint and
+// returns the diagnostics.
+func testConfigureProvider(t *testing.T, endpoint string) []*tfprotov6.Diagnostic {
+	server, err := providerserver.NewProtocol6WithError(provider.New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objectType := schemaResp.Provider.ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	attributes["endpoint"] = tftypes.NewValue(tftypes.String, endpoint)
+
+	config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{Config: &config})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.Diagnostics
+}
+
+func TestDaemon_ProviderEndpoint(t *testing.T) {
+	socket := testDaemon(t)
+
+	for _, diagnostic := range testConfigureProvider(t, socket) {
+		t.Errorf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	diagnostics := testConfigureProvider(t, filepath.Join(filepath.Dir(socket), "missing.sock"))
+
+	if len(diagnostics) != 1 || diagnostics[0].Summary != "Unable to Reach Daemon" {
+		t.Errorf("expected the missing daemon to be reported, got %v", diagnostics)
+	}
+}
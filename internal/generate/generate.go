@@ -0,0 +1,336 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// intListFlag is a comma separated list of integers.
+type intListFlag struct {
+	values *[]int
+}
+
+func (f intListFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, v := range *f.values {
+		parts = append(parts, strconv.Itoa(v))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (f intListFlag) Set(value string) error {
+	*f.values = nil
+
+	if value == "" {
+		return nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+
+		*f.values = append(*f.values, v)
+	}
+
+	return nil
+}
+
+// delayFlag is a delay in the format accepted by ParseDelay.
+type delayFlag struct {
+	spec *provider.DelaySpec
+}
+
+func (f delayFlag) String() string {
+	if f.spec == nil {
+		return ""
+	}
+
+	return FormatDelay(*f.spec)
+}
+
+func (f delayFlag) Set(value string) error {
+	spec, err := ParseDelay(value)
+	if err != nil {
+		return err
+	}
+
+	*f.spec = spec
+
+	return nil
+}
+
+// optionalDelayFlag is an optional delayFlag, where an empty value unsets
+// the delay.
+type optionalDelayFlag struct {
+	spec **provider.DelaySpec
+}
+
+func (f optionalDelayFlag) String() string {
+	if f.spec == nil || *f.spec == nil {
+		return ""
+	}
+
+	return FormatDelay(**f.spec)
+}
+
+func (f optionalDelayFlag) Set(value string) error {
+	if value == "" {
+		*f.spec = nil
+		return nil
+	}
+
+	spec, err := ParseDelay(value)
+	if err != nil {
+		return err
+	}
+
+	*f.spec = &spec
+
+	return nil
+}
+
+// nodeDelaysFlag is a repeatable "<node>=<delay>" flag.
+type nodeDelaysFlag struct {
+	delays *map[int]provider.DelaySpec
+}
+
+func (f nodeDelaysFlag) String() string {
+	if f.delays == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, node := range sortedNodes(*f.delays) {
+		parts = append(parts, fmt.Sprintf("%d=%s", node, FormatDelay((*f.delays)[node])))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (f nodeDelaysFlag) Set(value string) error {
+	node, delay, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected <node>=<delay>, got %q", value)
+	}
+
+	number, err := strconv.Atoi(node)
+	if err != nil {
+		return err
+	}
+
+	spec, err := ParseDelay(delay)
+	if err != nil {
+		return err
+	}
+
+	if *f.delays == nil {
+		*f.delays = map[int]provider.DelaySpec{}
+	}
+
+	(*f.delays)[number] = spec
+
+	return nil
+}
+
+func sortedNodes(delays map[int]provider.DelaySpec) []int {
+	nodes := make([]int, 0, len(delays))
+	for node := range delays {
+		nodes = append(nodes, node)
+	}
+
+	sort.Ints(nodes)
+
+	return nodes
+}
+
+// bindFlags binds the fields of the scenario to flags.
+func bindFlags(flags *flag.FlagSet, s *Scenario) {
+	flags.StringVar(&s.Title, "title", s.Title, "title of the generated README")
+	flags.StringVar(&s.Description, "description", s.Description, "description of the scenario in the generated README")
+	flags.StringVar(&s.Validates, "validates", s.Validates, "what the scenario is used to validate, for the generated README")
+	flags.Var(intListFlag{&s.ModuleWidths}, "modules", "comma separated number of module calls at each level of module nesting, empty for no modules")
+	flags.IntVar(&s.Width, "width", s.Width, "number of nodes in each layer of the innermost module")
+	flags.IntVar(&s.Depth, "depth", s.Depth, "number of layers of nodes in the innermost module")
+	flags.IntVar(&s.FanIn, "fan-in", s.FanIn, "number of nodes of the previous layer each node depends on")
+	flags.IntVar(&s.FanOut, "fan-out", s.FanOut, "number of nodes of the next layer that depend on each node")
+	flags.StringVar(&s.Gate, "gate", s.Gate, "what the enabled variable disables: none, nodes or modules")
+	flags.StringVar(&s.GateMode, "gate-mode", s.GateMode, "how gated blocks are disabled: count or for_each")
+	flags.IntVar(&s.EnabledNodes, "enabled-nodes", s.EnabledNodes, "number of nodes, counting from the first, that stay enabled when gating nodes")
+	flags.IntVar(&s.Resources, "resources", s.Resources, "relative weight of resource nodes")
+	flags.IntVar(&s.DataSources, "data-sources", s.DataSources, "relative weight of data source nodes")
+	flags.IntVar(&s.Functions, "functions", s.Functions, "relative weight of lag function nodes")
+	flags.Var(delayFlag{&s.Delay}, "delay", "delay of every node lag point: <ms>, fixed:<ms>, uniform:<min>:<max>, normal:<mean>:<stddev>, exponential:<mean> or lognormal:<mean>:<stddev>")
+	flags.Var(nodeDelaysFlag{&s.NodeDelays}, "node-delay", "delay of a single node as <node>=<delay>, may be repeated")
+	flags.Int64Var(&s.Seed, "seed", s.Seed, "seed added to the node number to seed sampled delays")
+	flags.Var(optionalDelayFlag{&s.ValidateDelay}, "validate-delay", "validation delay of every resource and data source node, empty for none")
+	flags.Var(optionalDelayFlag{&s.PlanDelay}, "plan-delay", "plan delay of every resource node, empty to leave it to the provider")
+	flags.IntVar(&s.PayloadSize, "payload-size", s.PayloadSize, "size in kilobytes of the payload generated by every resource and data source node, 0 for none")
+	flags.IntVar(&s.NestedDepth, "nested-depth", s.NestedDepth, "levels of the nested attributes generated by every resource node, 0 to leave them unset")
+	flags.IntVar(&s.NestedBreadth, "nested-breadth", s.NestedBreadth, "objects at each level of the nested attributes generated by every resource node, 0 to leave them unset")
+	flags.IntVar(&s.SetCardinality, "set-cardinality", s.SetCardinality, "objects in the set_nested attribute generated by every resource node, 0 to leave them unset")
+	flags.Var(optionalDelayFlag{&s.ProviderDelay}, "provider-delay", "delay of every provider lag point, empty for no provider configuration")
+	flags.BoolVar(&s.Imports, "imports", s.Imports, "write an import block for each resource node in place of its resource block, for -generate-config-out")
+	flags.StringVar(&s.NodePrefix, "node-prefix", s.NodePrefix, "prefix of the node names")
+	flags.StringVar(&s.Input, "input", s.Input, "input of the nodes in the first layer")
+	flags.StringVar(&s.ProviderSource, "provider-source", s.ProviderSource, "source of the testlagger provider")
+	flags.StringVar(&s.ProviderVersion, "provider-version", s.ProviderVersion, "version of the testlagger provider")
+}
+
+// Args returns the generate flags that reproduce the scenario, omitting
+// those left at the default of NewScenario.
+func (s Scenario) Args() []string {
+	return s.argsFrom(NewScenario())
+}
+
+// argsFrom returns the generate flags that turn base into the scenario.
+func (s Scenario) argsFrom(base Scenario) []string {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	bindFlags(flags, &s)
+
+	baseFlags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	bindFlags(baseFlags, &base)
+
+	var args []string
+
+	flags.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value == baseFlags.Lookup(f.Name).Value.String() {
+			return
+		}
+
+		// Node delays are added to those of the base, so listing all of
+		// them is always correct.
+		if f.Name == "node-delay" {
+			for _, node := range sortedNodes(s.NodeDelays) {
+				args = append(args, fmt.Sprintf("-node-delay=%d=%s", node, FormatDelay(s.NodeDelays[node])))
+			}
+			return
+		}
+
+		args = append(args, fmt.Sprintf("-%s=%s", f.Name, value))
+	})
+
+	return args
+}
+
+// shellQuote quotes an argument for a POSIX shell when necessary.
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=.,:/", r)
+	}) == -1 {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// generateOptions are the generate flags that are not part of the scenario.
+type generateOptions struct {
+	preset      string
+	output      string
+	listPresets bool
+}
+
+// newFlagSet returns the flags of the generate subcommand.
+func newFlagSet(s *Scenario, options *generateOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+
+	flags.StringVar(&options.preset, "preset", options.preset, "preset scenario to start from, other options override it")
+	flags.StringVar(&options.output, "output", options.output, "directory to write the fixture to")
+	flags.BoolVar(&options.listPresets, "list-presets", options.listPresets, "list the preset scenarios and exit")
+	bindFlags(flags, s)
+
+	return flags
+}
+
+// Run implements the generate subcommand.
+func Run(args []string, stdout io.Writer, stderr io.Writer) error {
+	var options generateOptions
+
+	// Find the preset first, so that the remaining flags override it.
+	presetFlags := newFlagSet(&Scenario{}, &options)
+	presetFlags.SetOutput(io.Discard)
+	_ = presetFlags.Parse(args)
+
+	scenario := NewScenario()
+	if options.preset != "" {
+		var err error
+
+		scenario, err = Preset(options.preset)
+		if err != nil {
+			return err
+		}
+	}
+
+	options = generateOptions{}
+
+	flags := newFlagSet(&scenario, &options)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: terraform-provider-testlagger generate [options] -output <directory>\n\n")
+		fmt.Fprintf(stderr, "Generates a performance test fixture. Presets: %s\n\n", strings.Join(PresetNames(), ", "))
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if options.listPresets {
+		for _, name := range PresetNames() {
+			fmt.Fprintln(stdout, name)
+		}
+
+		return nil
+	}
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	if options.output == "" {
+		flags.Usage()
+		return errors.New("-output is required")
+	}
+
+	// Record the shortest command that reproduces the fixture.
+	commandArgs := scenario.Args()
+
+	if options.preset != "" {
+		preset, _ := Preset(options.preset)
+		commandArgs = append([]string{"-preset=" + options.preset}, scenario.argsFrom(preset)...)
+	}
+
+	command := []string{"terraform-provider-testlagger", "generate"}
+	for _, arg := range commandArgs {
+		command = append(command, shellQuote(arg))
+	}
+	command = append(command, "-output=.")
+
+	files, err := Render(scenario, strings.Join(command, " "))
+	if err != nil {
+		return err
+	}
+
+	return WriteFiles(options.output, files)
+}
@@ -0,0 +1,330 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// TestPresetsMatchFixtures ensures the checked in performance fixtures are
+// what their presets generate.
+func TestPresetsMatchFixtures(t *testing.T) {
+	for _, name := range PresetNames() {
+		t.Run(name, func(t *testing.T) {
+			scenario, err := Preset(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := Render(scenario, "terraform-provider-testlagger generate -preset="+name+" -output=.")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for path, content := range files {
+				fixture, err := os.ReadFile(filepath.Join("..", "..", "performance", name, path))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(fixture) != content {
+					t.Errorf("%s does not match the preset, regenerate it with:\n  go run . generate -preset=%s -output=performance/%s\ngenerated:\n%s", path, name, name, content)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDelay(t *testing.T) {
+	for _, value := range []string{"1000", "fixed:1000", "uniform:500:1500", "normal:1000:200", "exponential:1000", "lognormal:1000:200"} {
+		spec, err := ParseDelay(value)
+		if err != nil {
+			t.Errorf("%s: %s", value, err)
+			continue
+		}
+
+		expected := value
+		if value == "1000" {
+			expected = "fixed:1000"
+		}
+
+		if actual := FormatDelay(spec); actual != expected {
+			t.Errorf("FormatDelay(ParseDelay(%q)) = %q", value, actual)
+		}
+	}
+
+	for _, value := range []string{"", "fast", "uniform:1", "uniform:2:1", "normal:a:b", "fixed:-1"} {
+		if _, err := ParseDelay(value); err == nil {
+			t.Errorf("expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestNodes(t *testing.T) {
+	s := NewScenario()
+	s.Width = 4
+	s.Depth = 3
+	s.FanIn = 2
+	s.FanOut = 2
+	s.Resources = 2
+	s.DataSources = 1
+	s.Functions = 1
+
+	nodes := s.nodes()
+	if len(nodes) != 12 {
+		t.Fatalf("expected 12 nodes, got %d", len(nodes))
+	}
+
+	var kinds []string
+	for _, n := range nodes[:4] {
+		kinds = append(kinds, n.kind)
+
+		if len(n.deps) != 0 {
+			t.Errorf("expected %s in the first layer to have no dependencies, got %v", n.name, n.deps)
+		}
+	}
+
+	if strings.Join(kinds, ",") != "resource,resource,data,function" {
+		t.Errorf("unexpected node kinds %v", kinds)
+	}
+
+	// Node 5 is the first node of the second layer: fan-in adds nodes 1
+	// and 2, fan-out adds nodes 1 and 4.
+	if !reflect.DeepEqual(nodes[4].deps, []int{1, 2, 4}) {
+		t.Errorf("unexpected dependencies of %s: %v", nodes[4].name, nodes[4].deps)
+	}
+
+	if !reflect.DeepEqual(nodes[11].deps, []int{5, 7, 8}) {
+		t.Errorf("unexpected dependencies of %s: %v", nodes[11].name, nodes[11].deps)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := NewScenario()
+	s.Width = 0
+	s.Gate = GateModules
+	s.GateMode = "maybe"
+	s.Resources = 0
+
+	err := s.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"width must be at least 1", "gating modules requires", "unknown gate mode", "node weights"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %s", expected, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := NewScenario()
+	s.ModuleWidths = []int{2, 3, 4}
+	s.Width = 2
+	s.Depth = 2
+	s.Gate = GateNodes
+	s.GateMode = GateModeForEach
+	s.Functions = 1
+	s.Delay, _ = ParseDelay("uniform:100:200")
+	planDelay := provider.FixedDelay(50)
+	s.PlanDelay = &planDelay
+
+	files, err := Render(s, "command")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	for _, expected := range []string{"main.tf", "README.md", "run.sh", filepath.Join("generated", "main.tf"), filepath.Join("generated-2", "main.tf"), filepath.Join("submodule", "main.tf")} {
+		if _, ok := files[expected]; !ok {
+			t.Errorf("expected %s in %v", expected, paths)
+		}
+	}
+
+	if strings.Count(files["main.tf"], `source = "./generated"`) != 2 {
+		t.Errorf("expected two calls to ./generated:\n%s", files["main.tf"])
+	}
+
+	if strings.Count(files[filepath.Join("generated", "main.tf")], `source = "../generated-2"`) != 3 {
+		t.Errorf("expected three calls to ../generated-2:\n%s", files[filepath.Join("generated", "main.tf")])
+	}
+
+	if strings.Count(files[filepath.Join("generated-2", "main.tf")], `source = "../submodule"`) != 4 {
+		t.Errorf("expected four calls to ../submodule:\n%s", files[filepath.Join("generated-2", "main.tf")])
+	}
+
+	submodule := files[filepath.Join("submodule", "main.tf")]
+	for _, expected := range []string{
+		`for_each   = var.enabled ? toset(["enabled"]) : toset([])`,
+		`create = { distribution = "uniform", min = 100, max = 200, seed = 1 }`,
+		`node2 = var.enabled ? provider::testlagger::lag({ distribution = "uniform", min = 100, max = 200, seed = 2 }, "hello") : ""`,
+		`input      = md5(join(",", concat(values(testlagger_lag.node1)[*].output)))`,
+		`plan_delay = 50`,
+		`variable "enabled"`,
+	} {
+		if !strings.Contains(submodule, expected) {
+			t.Errorf("expected %q in:\n%s", expected, submodule)
+		}
+	}
+}
+
+func TestRender_Imports(t *testing.T) {
+	s := NewScenario()
+	s.Width = 3
+	s.DataSources = 1
+	s.Imports = true
+	s.NodeDelays = map[int]provider.DelaySpec{3: provider.FixedDelay(50)}
+	planDelay := provider.FixedDelay(20)
+	s.PlanDelay = &planDelay
+
+	files, err := Render(s, "command")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main := files["main.tf"]
+	for _, expected := range []string{
+		"import {\n  to = testlagger_lag.node1\n  id = \"hello;plan=20;create=1000;read=1000;update=1000;delete=1000\"\n}",
+		`to = testlagger_lag.node3`,
+		`id = "hello;plan=20;create=50;read=50;update=50;delete=50"`,
+		`data "testlagger_lag" "node2"`,
+	} {
+		if !strings.Contains(main, expected) {
+			t.Errorf("expected %q in:\n%s", expected, main)
+		}
+	}
+
+	if strings.Contains(main, `resource "testlagger_lag"`) {
+		t.Errorf("expected no resource blocks, as they are generated:\n%s", main)
+	}
+
+	if !strings.Contains(files["run.sh"], "-generate-config-out=generated.tf") {
+		t.Errorf("expected run.sh to generate the configuration:\n%s", files["run.sh"])
+	}
+
+	s.Depth = 2
+	s.Delay, _ = ParseDelay("uniform:100:200")
+	s.PayloadSize = 4
+	s.NestedDepth = 2
+
+	err = s.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"imports require a depth of 1", "imports require fixed delays", "imports require no payload size", "imports require no nested attributes"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %s", expected, err)
+		}
+	}
+}
+
+func TestRender_PayloadSize(t *testing.T) {
+	s := NewScenario()
+	s.Width = 2
+	s.DataSources = 1
+	s.PayloadSize = 4
+
+	files, err := Render(s, "command")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main := files["main.tf"]
+	if strings.Count(main, "payload_size = 4") != 2 {
+		t.Errorf("expected a payload_size on the resource and data source nodes:\n%s", main)
+	}
+}
+
+func TestRender_Nested(t *testing.T) {
+	s := NewScenario()
+	s.Width = 2
+	s.DataSources = 1
+	s.NestedDepth = 3
+	s.NestedBreadth = 4
+	s.SetCardinality = 10
+
+	files, err := Render(s, "command")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main := files["main.tf"]
+	for _, expected := range []string{"nested_depth           = 3", "nested_breadth         = 4", "nested_set_cardinality = 10"} {
+		if strings.Count(main, expected) != 1 {
+			t.Errorf("expected %q on the resource node only:\n%s", expected, main)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	err := Run([]string{"-preset", "wide-graph-with-disabled-resource", "-width", "3", "-output", dir}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("%s: %s", err, stderr.String())
+	}
+
+	readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command := "terraform-provider-testlagger generate -preset=wide-graph-with-disabled-resource -width=3 -output=."
+	if !strings.Contains(string(readme), command) {
+		t.Errorf("expected README to contain %q:\n%s", command, readme)
+	}
+
+	submodule, err := os.ReadFile(filepath.Join(dir, "submodule", "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(submodule), `resource "testlagger_lag"`) != 3 || !strings.Contains(string(submodule), "create_delay = 10000") {
+		t.Errorf("unexpected submodule:\n%s", submodule)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	s := NewScenario()
+	s.Title = "My scenario"
+	s.ModuleWidths = []int{3}
+	s.FanOut = 2
+	s.Functions = 2
+	s.Delay, _ = ParseDelay("normal:100:10")
+	s.PlanDelay = &provider.DelaySpec{Distribution: provider.DelayDistributionExponential, Mean: 20}
+	validateDelay := provider.FixedDelay(10)
+	s.ValidateDelay = &validateDelay
+	s.NodeDelays = map[int]provider.DelaySpec{}
+	s.NodeDelays[1], _ = ParseDelay("exponential:50")
+
+	args := s.Args()
+
+	options := generateOptions{}
+	parsed := NewScenario()
+
+	if err := newFlagSet(&parsed, &options).Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(parsed, s) {
+		t.Errorf("flags %v parsed to %+v, expected %+v", args, parsed, s)
+	}
+}
@@ -0,0 +1,546 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// attribute is a single "name = expression" line of a block.
+type attribute struct {
+	name  string
+	value string
+}
+
+// writeAttributes writes attributes with their equals signs aligned, the way
+// "tofu fmt" does.
+func writeAttributes(b *strings.Builder, indent string, attributes []attribute) {
+	width := 0
+	for _, a := range attributes {
+		width = max(width, len(a.name))
+	}
+
+	for _, a := range attributes {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, a.name, a.value)
+	}
+}
+
+// writeBlock writes a block holding the given attributes, followed by any
+// multi-line attributes after a blank line.
+func writeBlock(b *strings.Builder, header string, attributes []attribute, multiline ...string) {
+	fmt.Fprintf(b, "%s {\n", header)
+	writeAttributes(b, "  ", attributes)
+
+	for _, m := range multiline {
+		fmt.Fprintf(b, "\n%s", m)
+	}
+
+	b.WriteString("}\n")
+}
+
+const requiredProvidersTemplate = `terraform {
+  required_providers {
+    testlagger = {
+      source  = %q
+      version = %q
+    }
+  }
+}
+`
+
+const enabledVariable = `variable "enabled" {
+  type    = bool
+  default = false
+}
+`
+
+// moduleDirectory returns the directory of the module at the given nesting
+// level, counting from 1. The innermost module is always "submodule".
+func (s Scenario) moduleDirectory(level int) string {
+	switch {
+	case level == len(s.ModuleWidths):
+		return "submodule"
+	case level == 1:
+		return "generated"
+	default:
+		return fmt.Sprintf("generated-%d", level)
+	}
+}
+
+// moduleCallPrefix returns the prefix of the names of the calls to the module
+// at the given nesting level.
+func (s Scenario) moduleCallPrefix(level int) string {
+	if level == len(s.ModuleWidths) {
+		return "module"
+	}
+
+	return "generated"
+}
+
+// gateExpression returns the count or for_each attribute of a gated block.
+func (s Scenario) gateExpression(enabled bool) attribute {
+	if s.GateMode == GateModeForEach {
+		if enabled {
+			return attribute{"for_each", `toset(["enabled"])`}
+		}
+		return attribute{"for_each", `var.enabled ? toset(["enabled"]) : toset([])`}
+	}
+
+	if enabled {
+		return attribute{"count", "1"}
+	}
+	return attribute{"count", "var.enabled ? 1 : 0"}
+}
+
+// node is a resource, data source or function call in the innermost module.
+type node struct {
+	number int
+	name   string
+	kind   string
+	gated  bool
+	delay  provider.DelaySpec
+	deps   []int
+}
+
+// nodes lays out the graph of the innermost module.
+func (s Scenario) nodes() []node {
+	total := s.Width * s.Depth
+	weights := s.Resources + s.DataSources + s.Functions
+
+	nodes := make([]node, 0, total)
+
+	for layer := 0; layer < s.Depth; layer++ {
+		for j := 0; j < s.Width; j++ {
+			n := node{
+				number: layer*s.Width + j + 1,
+				delay:  s.Delay,
+			}
+
+			n.name = s.NodePrefix
+			if total > 1 {
+				n.name = fmt.Sprintf("%s%d", s.NodePrefix, n.number)
+			}
+
+			switch slot := (n.number - 1) % weights; {
+			case slot < s.Resources:
+				n.kind = NodeResource
+			case slot < s.Resources+s.DataSources:
+				n.kind = NodeDataSource
+			default:
+				n.kind = NodeFunction
+			}
+
+			n.gated = s.Gate == GateNodes && n.number > s.EnabledNodes
+
+			if delay, ok := s.NodeDelays[n.number]; ok {
+				n.delay = delay
+			}
+
+			if layer > 0 {
+				deps := map[int]bool{}
+
+				// Node j depends on FanIn nodes of the previous layer,
+				// and each node of the previous layer is depended on by
+				// FanOut nodes of this layer.
+				for k := 0; k < s.FanIn; k++ {
+					deps[(j+k)%s.Width] = true
+				}
+
+				for k := 0; k < s.FanOut; k++ {
+					deps[((j-k)%s.Width+s.Width)%s.Width] = true
+				}
+
+				for dep := range deps {
+					n.deps = append(n.deps, (layer-1)*s.Width+dep+1)
+				}
+
+				sort.Ints(n.deps)
+			}
+
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+// reference returns an expression for the list of outputs of the node.
+func (s Scenario) reference(n node) string {
+	var address string
+
+	switch n.kind {
+	case NodeFunction:
+		return fmt.Sprintf("[local.%s]", n.name)
+	case NodeDataSource:
+		address = "data.testlagger_lag." + n.name
+	default:
+		address = "testlagger_lag." + n.name
+	}
+
+	if s.Gate != GateNodes {
+		return fmt.Sprintf("[%s.output]", address)
+	}
+
+	if s.GateMode == GateModeForEach {
+		return fmt.Sprintf("values(%s)[*].output", address)
+	}
+
+	return address + "[*].output"
+}
+
+// distributionObject returns an HCL object expression for a delay
+// distribution.
+func distributionObject(spec provider.DelaySpec, seed int64) string {
+	attributes := []string{fmt.Sprintf("distribution = %q", spec.Distribution)}
+
+	switch spec.Distribution {
+	case provider.DelayDistributionUniform:
+		attributes = append(attributes, fmt.Sprintf("min = %d", *spec.Min), fmt.Sprintf("max = %d", *spec.Max))
+	case provider.DelayDistributionNormal, provider.DelayDistributionLogNormal:
+		attributes = append(attributes, fmt.Sprintf("mean = %d", spec.Mean), fmt.Sprintf("stddev = %d", spec.StdDev))
+	case provider.DelayDistributionExponential:
+		attributes = append(attributes, fmt.Sprintf("mean = %d", spec.Mean))
+	default:
+		attributes = append(attributes, fmt.Sprintf("value = %d", spec.Value))
+	}
+
+	attributes = append(attributes, fmt.Sprintf("seed = %d", seed))
+
+	return "{ " + strings.Join(attributes, ", ") + " }"
+}
+
+// lagPointDelay is the delay of a single lag point.
+type lagPointDelay struct {
+	lagPoint string
+	spec     provider.DelaySpec
+}
+
+// lagPointDelays returns the same delay for each of the lag points.
+func lagPointDelays(spec provider.DelaySpec, lagPoints ...string) []lagPointDelay {
+	delays := make([]lagPointDelay, 0, len(lagPoints))
+	for _, lagPoint := range lagPoints {
+		delays = append(delays, lagPointDelay{lagPoint, spec})
+	}
+
+	return delays
+}
+
+// delayAttributes returns the fixed delays as "<lag point>_delay"
+// attributes, and any other delays as a multi-line delay_distributions
+// attribute.
+func delayAttributes(seed int64, delays ...lagPointDelay) ([]attribute, string) {
+	var attributes, entries []attribute
+
+	for _, delay := range delays {
+		if delay.spec.Distribution == provider.DelayDistributionFixed {
+			attributes = append(attributes, attribute{delay.lagPoint + "_delay", fmt.Sprint(delay.spec.Value)})
+		} else {
+			entries = append(entries, attribute{delay.lagPoint, distributionObject(delay.spec, seed)})
+		}
+	}
+
+	if len(entries) == 0 {
+		return attributes, ""
+	}
+
+	var b strings.Builder
+	b.WriteString("  delay_distributions = {\n")
+	writeAttributes(&b, "    ", entries)
+	b.WriteString("  }\n")
+
+	return attributes, b.String()
+}
+
+func (s Scenario) renderNode(b *strings.Builder, n node, nodes []node) {
+	input := fmt.Sprintf("%q", s.Input)
+
+	if len(n.deps) > 0 {
+		var references []string
+		for _, dep := range n.deps {
+			references = append(references, s.reference(nodes[dep-1]))
+		}
+
+		input = fmt.Sprintf(`md5(join(",", concat(%s)))`, strings.Join(references, ", "))
+	}
+
+	seed := s.Seed + int64(n.number)
+
+	if n.kind == NodeFunction {
+		delay := fmt.Sprint(n.delay.Value)
+		if n.delay.Distribution != provider.DelayDistributionFixed {
+			delay = distributionObject(n.delay, seed)
+		}
+
+		call := fmt.Sprintf("provider::testlagger::lag(%s, %s)", delay, input)
+		if n.gated {
+			call = fmt.Sprintf(`var.enabled ? %s : ""`, call)
+		}
+
+		writeBlock(b, "locals", []attribute{{n.name, call}})
+		return
+	}
+
+	if s.Imports && n.kind == NodeResource {
+		writeBlock(b, "import", []attribute{
+			{"to", "testlagger_lag." + n.name},
+			{"id", fmt.Sprintf("%q", s.importID(n))},
+		})
+		return
+	}
+
+	var attributes []attribute
+	if s.Gate == GateNodes {
+		attributes = append(attributes, s.gateExpression(!n.gated))
+	}
+
+	header := fmt.Sprintf("resource \"testlagger_lag\" %q", n.name)
+
+	var lagPoints []lagPointDelay
+	if s.ValidateDelay != nil {
+		lagPoints = append(lagPoints, lagPointDelay{"validate", *s.ValidateDelay})
+	}
+
+	if n.kind == NodeDataSource {
+		header = fmt.Sprintf("data \"testlagger_lag\" %q", n.name)
+		lagPoints = append(lagPoints, lagPointDelays(n.delay, "read")...)
+	} else {
+		if s.PlanDelay != nil {
+			lagPoints = append(lagPoints, lagPointDelay{"plan", *s.PlanDelay})
+		}
+		lagPoints = append(lagPoints, lagPointDelays(n.delay, "create", "read", "update", "delete")...)
+	}
+
+	delays, distributions := delayAttributes(seed, lagPoints...)
+	attributes = append(attributes, delays...)
+	attributes = append(attributes, attribute{"input", input})
+
+	if s.PayloadSize > 0 {
+		attributes = append(attributes, attribute{"payload_size", fmt.Sprint(s.PayloadSize)})
+	}
+
+	if n.kind == NodeResource {
+		for _, nested := range []struct {
+			name  string
+			value int
+		}{
+			{"nested_depth", s.NestedDepth},
+			{"nested_breadth", s.NestedBreadth},
+			{"nested_set_cardinality", s.SetCardinality},
+		} {
+			if nested.value > 0 {
+				attributes = append(attributes, attribute{nested.name, fmt.Sprint(nested.value)})
+			}
+		}
+	}
+
+	if distributions == "" {
+		writeBlock(b, header, attributes)
+	} else {
+		writeBlock(b, header, attributes, distributions)
+	}
+}
+
+// importID returns the import ID of a resource node, which restores its
+// input and fixed delays, for example "hello;create=1000;read=1000".
+func (s Scenario) importID(n node) string {
+	parts := []string{s.Input}
+
+	if s.ValidateDelay != nil {
+		parts = append(parts, fmt.Sprintf("validate=%d", s.ValidateDelay.Value))
+	}
+
+	if s.PlanDelay != nil {
+		parts = append(parts, fmt.Sprintf("plan=%d", s.PlanDelay.Value))
+	}
+
+	for _, lagPoint := range []string{"create", "read", "update", "delete"} {
+		parts = append(parts, fmt.Sprintf("%s=%d", lagPoint, n.delay.Value))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// renderNodes returns the body of the innermost module.
+func (s Scenario) renderNodes() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, requiredProvidersTemplate, s.ProviderSource, s.ProviderVersion)
+
+	nodes := s.nodes()
+	for _, n := range nodes {
+		b.WriteString("\n")
+		s.renderNode(&b, n, nodes)
+	}
+
+	if s.Gate == GateNodes {
+		b.WriteString("\n")
+		b.WriteString(enabledVariable)
+	}
+
+	return b.String()
+}
+
+// renderProvider returns the provider configuration block.
+func (s Scenario) renderProvider() string {
+	var b strings.Builder
+
+	delays, distributions := delayAttributes(s.Seed, lagPointDelays(*s.ProviderDelay, "client_initialize", "datasource_configure", "resource_configure", "resource_import_state")...)
+
+	if distributions == "" {
+		writeBlock(&b, `provider "testlagger"`, delays)
+	} else {
+		writeBlock(&b, `provider "testlagger"`, delays, distributions)
+	}
+
+	return b.String()
+}
+
+// renderRoot returns the body of the root module.
+func (s Scenario) renderRoot() string {
+	if len(s.ModuleWidths) == 0 {
+		if s.ProviderDelay == nil {
+			return s.renderNodes()
+		}
+
+		body := s.renderNodes()
+		header := fmt.Sprintf(requiredProvidersTemplate, s.ProviderSource, s.ProviderVersion)
+
+		return header + "\n" + s.renderProvider() + strings.TrimPrefix(body, header)
+	}
+
+	var sections []string
+
+	if s.ProviderDelay != nil {
+		sections = append(sections, fmt.Sprintf(requiredProvidersTemplate, s.ProviderSource, s.ProviderVersion), s.renderProvider())
+	}
+
+	if s.Gate == GateModules {
+		sections = append(sections, enabledVariable)
+	}
+
+	for i := 1; i <= s.ModuleWidths[0]; i++ {
+		var b strings.Builder
+
+		var attributes []attribute
+		if s.Gate == GateModules {
+			attributes = append(attributes, s.gateExpression(false))
+		}
+		attributes = append(attributes, attribute{"source", fmt.Sprintf("%q", "./"+s.moduleDirectory(1))})
+
+		writeBlock(&b, fmt.Sprintf("module \"%s-%d\"", s.moduleCallPrefix(1), i), attributes)
+		sections = append(sections, b.String())
+	}
+
+	return strings.Join(sections, "\n")
+}
+
+// renderModule returns the body of the module at the given nesting level,
+// which calls the module at the next level.
+func (s Scenario) renderModule(level int) string {
+	var b strings.Builder
+
+	for i := 1; i <= s.ModuleWidths[level]; i++ {
+		fmt.Fprintf(&b, "module \"%s-%d\" { source = %q }\n", s.moduleCallPrefix(level+1), i, "../"+s.moduleDirectory(level+1))
+	}
+
+	return b.String()
+}
+
+const runScript = `# Generated by "terraform-provider-testlagger generate", see README.md.
+tofu init
+time tofu plan -out out.tfplan
+time tofu show -json out.tfplan > /dev/null
+`
+
+const importRunScript = `# Generated by "terraform-provider-testlagger generate", see README.md.
+tofu init
+rm -f generated.tf
+time tofu plan -generate-config-out=generated.tf -out out.tfplan
+time tofu show -json out.tfplan > /dev/null
+`
+
+// renderReadme returns the README of the fixture. The command is the
+// generate command line that reproduces the fixture.
+func (s Scenario) renderReadme(command string) string {
+	var b strings.Builder
+
+	title := s.Title
+	if title == "" {
+		title = "Generated scenario"
+	}
+
+	fmt.Fprintf(&b, "# %s\n", title)
+
+	if s.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", s.Description)
+	}
+
+	if s.Validates != "" {
+		fmt.Fprintf(&b, "\n## Used to validate:\n\n%s\n", s.Validates)
+	}
+
+	fmt.Fprintf(&b, "\n## Regenerating\n\nThis directory is generated, run `run.sh` after regenerating it with:\n\n```shell\n%s\n```\n", command)
+
+	return b.String()
+}
+
+// Render returns the files of the fixture keyed by their path relative to
+// the fixture directory. The command is recorded in the README.
+func Render(s Scenario, command string) (map[string]string, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{
+		"main.tf":   s.renderRoot(),
+		"run.sh":    runScript,
+		"README.md": s.renderReadme(command),
+	}
+
+	if s.Imports {
+		files["run.sh"] = importRunScript
+	}
+
+	for level := 1; level <= len(s.ModuleWidths); level++ {
+		path := filepath.Join(s.moduleDirectory(level), "main.tf")
+
+		if level == len(s.ModuleWidths) {
+			files[path] = s.renderNodes()
+		} else {
+			files[path] = s.renderModule(level)
+		}
+	}
+
+	return files, nil
+}
+
+// WriteFiles writes the rendered files below dir.
+func WriteFiles(dir string, files map[string]string) error {
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		mode := os.FileMode(0o644)
+		if filepath.Ext(path) == ".sh" {
+			mode = 0o755
+		}
+
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
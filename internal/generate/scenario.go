@@ -0,0 +1,418 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package generate builds the performance test fixtures from a description
+// of the shape of the graph.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+)
+
+// Gating controls which nodes of the graph are disabled through the
+// "enabled" variable.
+const (
+	// GateNone leaves every node enabled.
+	GateNone = "none"
+
+	// GateNodes disables the resources, data sources and function calls in
+	// the innermost module.
+	GateNodes = "nodes"
+
+	// GateModules disables the module calls in the root module.
+	GateModules = "modules"
+)
+
+// Gating modes.
+const (
+	GateModeCount   = "count"
+	GateModeForEach = "for_each"
+)
+
+// Node kinds.
+const (
+	NodeResource   = "resource"
+	NodeDataSource = "data"
+	NodeFunction   = "function"
+)
+
+// Scenario describes a performance test fixture.
+//
+// The root module calls ModuleWidths[0] copies of a module, which in turn
+// calls ModuleWidths[1] copies of the next module, and so on. The innermost
+// module holds Depth layers of Width nodes, where each node after the first
+// layer depends on FanIn nodes of the layer before it, and each node before
+// the last layer is depended on by at least FanOut nodes of the layer after
+// it.
+type Scenario struct {
+	// Title and Description are written to the README of the fixture,
+	// followed by Validates under a "Used to validate" heading.
+	Title       string
+	Description string
+	Validates   string
+
+	ModuleWidths []int
+
+	Width  int
+	Depth  int
+	FanIn  int
+	FanOut int
+
+	// Gate is one of GateNone, GateNodes or GateModules, and GateMode is one
+	// of GateModeCount or GateModeForEach. EnabledNodes is the number of
+	// nodes, counting from the first, that stay enabled when gating nodes.
+	Gate         string
+	GateMode     string
+	EnabledNodes int
+
+	// Resources, DataSources and Functions are the relative weights of each
+	// kind of node.
+	Resources   int
+	DataSources int
+	Functions   int
+
+	// Delay is used for every lag point of every node, other than those
+	// listed in NodeDelays, keyed by the 1-based node number. Delays other
+	// than fixed ones are sampled by the provider, seeded from Seed and the
+	// node number.
+	Delay      provider.DelaySpec
+	NodeDelays map[int]provider.DelaySpec
+	Seed       int64
+
+	// ValidateDelay, when set, is the validation delay of every resource and
+	// data source node, and PlanDelay the plan delay of every resource node.
+	ValidateDelay *provider.DelaySpec
+	PlanDelay     *provider.DelaySpec
+
+	// PayloadSize, when positive, is the payload_size in kilobytes of every
+	// resource and data source node.
+	PayloadSize int
+
+	// NestedDepth, NestedBreadth and SetCardinality, when positive, shape
+	// the generated nested attributes of every resource node.
+	NestedDepth    int
+	NestedBreadth  int
+	SetCardinality int
+
+	// ProviderDelay is used for every provider lag point when set.
+	ProviderDelay *provider.DelaySpec
+
+	// Imports replaces the block of each resource node with an import block,
+	// whose ID encodes the input and delays of the node, so that the
+	// resource configuration is generated with -generate-config-out.
+	Imports bool
+
+	// NodePrefix names the nodes, for example "iter" gives "iter1",
+	// "iter2"... A single node is named NodePrefix.
+	NodePrefix string
+	Input      string
+
+	ProviderSource  string
+	ProviderVersion string
+}
+
+// NewScenario returns a scenario with a single 1000ms resource.
+func NewScenario() Scenario {
+	return Scenario{
+		Width:           1,
+		Depth:           1,
+		FanIn:           1,
+		Gate:            GateNone,
+		GateMode:        GateModeCount,
+		Resources:       1,
+		Delay:           provider.FixedDelay(1000),
+		NodePrefix:      "node",
+		Input:           "hello",
+		ProviderSource:  "taliesins/testlagger",
+		ProviderVersion: "1.0.0",
+	}
+}
+
+// Validate checks that the scenario describes a graph that can be built.
+func (s Scenario) Validate() error {
+	var errs []error
+
+	for i, width := range s.ModuleWidths {
+		if width < 1 {
+			errs = append(errs, fmt.Errorf("module width %d must be at least 1, got %d", i+1, width))
+		}
+	}
+
+	if s.Width < 1 {
+		errs = append(errs, fmt.Errorf("width must be at least 1, got %d", s.Width))
+	}
+
+	if s.Depth < 1 {
+		errs = append(errs, fmt.Errorf("depth must be at least 1, got %d", s.Depth))
+	}
+
+	if s.FanIn < 0 || s.FanOut < 0 {
+		errs = append(errs, fmt.Errorf("fan-in and fan-out must not be negative"))
+	}
+
+	switch s.Gate {
+	case GateNone, GateNodes:
+	case GateModules:
+		if len(s.ModuleWidths) == 0 {
+			errs = append(errs, fmt.Errorf("gating modules requires at least one module width"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown gate %q, expected one of %s, %s or %s", s.Gate, GateNone, GateNodes, GateModules))
+	}
+
+	if s.GateMode != GateModeCount && s.GateMode != GateModeForEach {
+		errs = append(errs, fmt.Errorf("unknown gate mode %q, expected %s or %s", s.GateMode, GateModeCount, GateModeForEach))
+	}
+
+	if s.Resources < 0 || s.DataSources < 0 || s.Functions < 0 || s.Resources+s.DataSources+s.Functions == 0 {
+		errs = append(errs, fmt.Errorf("node weights must not be negative and at least one must be positive"))
+	}
+
+	if err := s.Delay.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("delay: %w", err))
+	}
+
+	for node, delay := range s.NodeDelays {
+		if node < 1 || node > s.Width*s.Depth {
+			errs = append(errs, fmt.Errorf("node delay for node %d is out of range 1-%d", node, s.Width*s.Depth))
+		}
+
+		if err := delay.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("node %d delay: %w", node, err))
+		}
+	}
+
+	if s.ValidateDelay != nil {
+		if err := s.ValidateDelay.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("validate delay: %w", err))
+		}
+	}
+
+	if s.PlanDelay != nil {
+		if err := s.PlanDelay.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("plan delay: %w", err))
+		}
+	}
+
+	if s.PayloadSize < 0 {
+		errs = append(errs, fmt.Errorf("payload size must not be negative, got %d", s.PayloadSize))
+	}
+
+	if s.NestedDepth < 0 || s.NestedBreadth < 0 || s.SetCardinality < 0 {
+		errs = append(errs, fmt.Errorf("nested depth, nested breadth and set cardinality must not be negative"))
+	}
+
+	if s.ProviderDelay != nil {
+		if err := s.ProviderDelay.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("provider delay: %w", err))
+		}
+	}
+
+	if s.NodePrefix == "" {
+		errs = append(errs, fmt.Errorf("node prefix must not be empty"))
+	}
+
+	if s.Imports {
+		errs = append(errs, s.validateImports()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateImports checks that every resource node can be imported from the
+// root module with an import ID that is known when planning.
+func (s Scenario) validateImports() []error {
+	var errs []error
+
+	if len(s.ModuleWidths) > 0 {
+		errs = append(errs, fmt.Errorf("imports require the nodes to be in the root module, got module widths %v", s.ModuleWidths))
+	}
+
+	if s.Gate != GateNone {
+		errs = append(errs, fmt.Errorf("imports require a gate of %s, got %s", GateNone, s.Gate))
+	}
+
+	if s.Depth != 1 {
+		errs = append(errs, fmt.Errorf("imports require a depth of 1, as the input of dependent nodes is not known when planning, got %d", s.Depth))
+	}
+
+	fixed := func(name string, spec *provider.DelaySpec) {
+		if spec != nil && spec.Distribution != provider.DelayDistributionFixed {
+			errs = append(errs, fmt.Errorf("imports require fixed delays, as import IDs only hold milliseconds, got %s delay %s", name, FormatDelay(*spec)))
+		}
+	}
+
+	for _, n := range s.nodes() {
+		if n.kind == NodeResource {
+			fixed(fmt.Sprintf("node %d", n.number), &n.delay)
+		}
+	}
+
+	fixed("validate", s.ValidateDelay)
+	fixed("plan", s.PlanDelay)
+
+	if s.PayloadSize > 0 {
+		errs = append(errs, fmt.Errorf("imports require no payload size, as import IDs do not hold it, got %d", s.PayloadSize))
+	}
+
+	if s.NestedDepth > 0 || s.NestedBreadth > 0 || s.SetCardinality > 0 {
+		errs = append(errs, fmt.Errorf("imports require no nested attributes, as import IDs do not hold their shape"))
+	}
+
+	return errs
+}
+
+// ParseDelay parses a delay written as "<distribution>:<parameters>":
+// "fixed:1000", "uniform:500:1500", "normal:1000:200",
+// "exponential:1000" or "lognormal:1000:200". A plain number is a fixed
+// delay.
+func ParseDelay(value string) (provider.DelaySpec, error) {
+	parts := strings.Split(value, ":")
+
+	var numbers []int64
+	for _, part := range parts[1:] {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return provider.DelaySpec{}, fmt.Errorf("invalid delay %q: %w", value, err)
+		}
+		numbers = append(numbers, n)
+	}
+
+	expect := func(count int, usage string) error {
+		if len(numbers) != count {
+			return fmt.Errorf("invalid delay %q, expected %s", value, usage)
+		}
+		return nil
+	}
+
+	var spec provider.DelaySpec
+	var err error
+
+	switch parts[0] {
+	case provider.DelayDistributionFixed:
+		if err = expect(1, "fixed:<value>"); err == nil {
+			spec = provider.FixedDelay(numbers[0])
+		}
+	case provider.DelayDistributionUniform:
+		if err = expect(2, "uniform:<min>:<max>"); err == nil {
+			spec = provider.DelaySpec{Distribution: parts[0], Min: &numbers[0], Max: &numbers[1]}
+		}
+	case provider.DelayDistributionNormal, provider.DelayDistributionLogNormal:
+		if err = expect(2, parts[0]+":<mean>:<stddev>"); err == nil {
+			spec = provider.DelaySpec{Distribution: parts[0], Mean: numbers[0], StdDev: numbers[1]}
+		}
+	case provider.DelayDistributionExponential:
+		if err = expect(1, "exponential:<mean>"); err == nil {
+			spec = provider.DelaySpec{Distribution: parts[0], Mean: numbers[0]}
+		}
+	default:
+		n, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil {
+			return spec, fmt.Errorf("invalid delay %q, expected a number or <distribution>:<parameters>", value)
+		}
+		spec = provider.FixedDelay(n)
+	}
+
+	if err != nil {
+		return spec, err
+	}
+
+	return spec, spec.Validate()
+}
+
+// FormatDelay is the inverse of ParseDelay.
+func FormatDelay(spec provider.DelaySpec) string {
+	switch spec.Distribution {
+	case provider.DelayDistributionUniform:
+		return fmt.Sprintf("%s:%d:%d", spec.Distribution, *spec.Min, *spec.Max)
+	case provider.DelayDistributionNormal, provider.DelayDistributionLogNormal:
+		return fmt.Sprintf("%s:%d:%d", spec.Distribution, spec.Mean, spec.StdDev)
+	case provider.DelayDistributionExponential:
+		return fmt.Sprintf("%s:%d", spec.Distribution, spec.Mean)
+	default:
+		return fmt.Sprintf("%s:%d", provider.DelayDistributionFixed, spec.Value)
+	}
+}
+
+// presets reproduce the hand written scenarios in the performance directory.
+var presets = map[string]func() Scenario{
+	"wide-graph-with-disabled-data-sources": func() Scenario {
+		s := NewScenario()
+		s.Title = "Wide graph with disabled data sources"
+		s.Description = "The test in this directory exists to simulate a wide graph scenario with disabled data sources."
+		s.Validates = "If dependency graph and execution will skip over data sources that have been disabled."
+		s.ModuleWidths = []int{10, 10}
+		s.Gate = GateNodes
+		s.Resources = 0
+		s.DataSources = 1
+		s.NodePrefix = "test"
+		delay := provider.FixedDelay(1000)
+		s.ProviderDelay = &delay
+		return s
+	},
+	"wide-graph-with-disabled-module-data-sources": func() Scenario {
+		s := NewScenario()
+		s.Title = "Wide graph with disabled modules that have data sources"
+		s.Description = "The test in this directory exists to simulate a wide graph scenario with disabled modules that have data sources."
+		s.Validates = "If dependency graph and execution will skip over modules that have been disabled."
+		s.ModuleWidths = []int{10, 10}
+		s.Gate = GateModules
+		s.Resources = 0
+		s.DataSources = 1
+		s.NodePrefix = "test"
+		return s
+	},
+	"wide-graph-with-disabled-module-resource": func() Scenario {
+		s := NewScenario()
+		s.Title = "Wide graph with disabled modules that have resources"
+		s.Description = "The test in this directory exists to simulate a wide graph scenario with disabled modules that have resources."
+		s.Validates = "If dependency graph and execution will skip over modules that have been disabled."
+		s.ModuleWidths = []int{10, 10}
+		s.Gate = GateModules
+		s.NodePrefix = "iter"
+		return s
+	},
+	"wide-graph-with-disabled-resource": func() Scenario {
+		s := NewScenario()
+		s.Title = "Wide graph with disabled resources"
+		s.Description = "The test in this directory exists to simulate a wide graph scenario with disabled resources."
+		s.Validates = "If dependency graph and execution will skip over resources that have been disabled."
+		s.ModuleWidths = []int{10, 10}
+		s.Width = 7
+		s.Gate = GateNodes
+		s.EnabledNodes = 1
+		s.NodeDelays = map[int]provider.DelaySpec{2: provider.FixedDelay(10000)}
+		s.NodePrefix = "iter"
+		return s
+	},
+}
+
+// Preset returns the named preset scenario.
+func Preset(name string) (Scenario, error) {
+	preset, ok := presets[name]
+	if !ok {
+		return Scenario{}, fmt.Errorf("unknown preset %q, expected one of: %s", name, strings.Join(PresetNames(), ", "))
+	}
+
+	return preset(), nil
+}
+
+// PresetNames returns the names of the preset scenarios in order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,84 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"sync"
+)
+
+// ConcurrencyTracker records how many lag points are in flight at once, both
+// overall and per operation, along with the peaks reached since the provider
+// was configured.
+type ConcurrencyTracker struct {
+	mu                    sync.Mutex
+	inFlight              int64
+	peakInFlight          int64
+	operationInFlight     map[string]int64
+	operationPeakInFlight map[string]int64
+}
+
+// ConcurrencySnapshot is a point in time copy of a ConcurrencyTracker.
+type ConcurrencySnapshot struct {
+	InFlight              int64
+	PeakInFlight          int64
+	OperationInFlight     map[string]int64
+	OperationPeakInFlight map[string]int64
+}
+
+func NewConcurrencyTracker() *ConcurrencyTracker {
+	return &ConcurrencyTracker{
+		operationInFlight:     map[string]int64{},
+		operationPeakInFlight: map[string]int64{},
+	}
+}
+
+// Start records that an operation is in flight. The returned function must be
+// called once the operation has finished.
+func (t *ConcurrencyTracker) Start(operation string) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight++
+	t.peakInFlight = max(t.peakInFlight, t.inFlight)
+
+	t.operationInFlight[operation]++
+	t.operationPeakInFlight[operation] = max(t.operationPeakInFlight[operation], t.operationInFlight[operation])
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.inFlight--
+			t.operationInFlight[operation]--
+		})
+	}
+}
+
+// Snapshot returns the current and peak number of operations in flight.
+func (t *ConcurrencyTracker) Snapshot() ConcurrencySnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := ConcurrencySnapshot{
+		InFlight:              t.inFlight,
+		PeakInFlight:          t.peakInFlight,
+		OperationInFlight:     make(map[string]int64, len(t.operationInFlight)),
+		OperationPeakInFlight: make(map[string]int64, len(t.operationPeakInFlight)),
+	}
+
+	for operation, inFlight := range t.operationInFlight {
+		snapshot.OperationInFlight[operation] = inFlight
+	}
+
+	for operation, peak := range t.operationPeakInFlight {
+		snapshot.OperationPeakInFlight[operation] = peak
+	}
+
+	return snapshot
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ConcurrencyDataSource{}
+
+func NewConcurrencyDataSource() datasource.DataSource {
+	return &ConcurrencyDataSource{}
+}
+
+type ConcurrencyDataSource struct {
+	client *TestLaggerClient
+}
+
+type concurrencyDataSourceModel struct {
+	InFlight                types.Int64 `tfsdk:"in_flight"`
+	PeakInFlight            types.Int64 `tfsdk:"peak_in_flight"`
+	InFlightByOperation     types.Map   `tfsdk:"in_flight_by_operation"`
+	PeakInFlightByOperation types.Map   `tfsdk:"peak_in_flight_by_operation"`
+}
+
+func (d *ConcurrencyDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_concurrency"
+}
+
+func (d *ConcurrencyDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reports how many lag points have been in flight at once in this provider process. Use `depends_on` to read it once the operations being measured have completed.",
+		Attributes: map[string]schema.Attribute{
+			"in_flight": schema.Int64Attribute{
+				MarkdownDescription: "Number of lag points currently in flight",
+				Computed:            true,
+			},
+			"peak_in_flight": schema.Int64Attribute{
+				MarkdownDescription: "Highest number of lag points in flight at once",
+				Computed:            true,
+			},
+			"in_flight_by_operation": schema.MapAttribute{
+				MarkdownDescription: "Number of lag points currently in flight, keyed by operation",
+				ElementType:         types.Int64Type,
+				Computed:            true,
+			},
+			"peak_in_flight_by_operation": schema.MapAttribute{
+				MarkdownDescription: "Highest number of lag points in flight at once, keyed by operation",
+				ElementType:         types.Int64Type,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *ConcurrencyDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*TestLaggerClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *TestLaggerClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *ConcurrencyDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data concurrencyDataSourceModel
+
+	// Read configuration data into the model
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	snapshot := d.client.Concurrency.Snapshot()
+
+	// Set output values
+	data.InFlight = types.Int64Value(snapshot.InFlight)
+	data.PeakInFlight = types.Int64Value(snapshot.PeakInFlight)
+
+	data.InFlightByOperation, diags = types.MapValueFrom(ctx, types.Int64Type, snapshot.OperationInFlight)
+	resp.Diagnostics.Append(diags...)
+
+	data.PeakInFlightByOperation, diags = types.MapValueFrom(ctx, types.Int64Type, snapshot.OperationPeakInFlight)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestConcurrencyDataSource(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	count        = 3
+	create_delay = 500
+	input        = "hello-${count.index}"
+}
+
+data "testlagger_concurrency" "test" {
+	depends_on = [testlagger_lag.test]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.testlagger_concurrency.test", "in_flight", "0"),
+					resource.TestCheckResourceAttr("data.testlagger_concurrency.test", "peak_in_flight_by_operation.resource_create", "3"),
+				),
+			},
+		},
+	})
+}
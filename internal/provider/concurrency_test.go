@@ -0,0 +1,80 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrencyTracker(t *testing.T) {
+	tracker := NewConcurrencyTracker()
+
+	finishCreate := tracker.Start("resource_create")
+	finishRead := tracker.Start("resource_read")
+	finishCreate2 := tracker.Start("resource_create")
+
+	snapshot := tracker.Snapshot()
+
+	if snapshot.InFlight != 3 || snapshot.PeakInFlight != 3 {
+		t.Fatalf("expected 3 in flight with a peak of 3, got %d with a peak of %d", snapshot.InFlight, snapshot.PeakInFlight)
+	}
+
+	finishCreate()
+	finishCreate()
+	finishRead()
+
+	snapshot = tracker.Snapshot()
+
+	if snapshot.InFlight != 1 || snapshot.PeakInFlight != 3 {
+		t.Fatalf("expected 1 in flight with a peak of 3, got %d with a peak of %d", snapshot.InFlight, snapshot.PeakInFlight)
+	}
+
+	if got := snapshot.OperationPeakInFlight["resource_create"]; got != 2 {
+		t.Fatalf("expected resource_create peak of 2, got %d", got)
+	}
+
+	if got := snapshot.OperationPeakInFlight["resource_read"]; got != 1 {
+		t.Fatalf("expected resource_read peak of 1, got %d", got)
+	}
+
+	finishCreate2()
+
+	if got := tracker.Snapshot().InFlight; got != 0 {
+		t.Fatalf("expected nothing in flight, got %d", got)
+	}
+}
+
+func TestConcurrencyTracker_Parallel(t *testing.T) {
+	tracker := NewConcurrencyTracker()
+
+	var started, release sync.WaitGroup
+	var finished sync.WaitGroup
+
+	started.Add(10)
+	release.Add(1)
+
+	for i := 0; i < 10; i++ {
+		finished.Add(1)
+		go func() {
+			defer finished.Done()
+			defer tracker.Start("resource_create")()
+
+			started.Done()
+			release.Wait()
+		}()
+	}
+
+	started.Wait()
+	release.Done()
+	finished.Wait()
+
+	snapshot := tracker.Snapshot()
+
+	if snapshot.InFlight != 0 || snapshot.PeakInFlight != 10 {
+		t.Fatalf("expected nothing in flight with a peak of 10, got %d with a peak of %d", snapshot.InFlight, snapshot.PeakInFlight)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ObjectStore holds the remote objects of lag resources, either in a
+// directory with RemoteStore or in a daemon with DaemonClient.
+type ObjectStore interface {
+	// Get returns the object with the given id, or nil if there is none.
+	Get(id string) (*RemoteObject, error)
+
+	// Put creates or replaces the object.
+	Put(object RemoteObject) error
+
+	// Delete deletes the object with the given id, if there is one.
+	Delete(id string) error
+}
+
+// Ensure the stores satisfy the interface.
+var _ ObjectStore = &RemoteStore{}
+var _ ObjectStore = &DaemonClient{}
+
+// daemonRequestTimeout bounds each request to the daemon, so that a daemon
+// that has stopped responding fails the operation rather than hanging it.
+const daemonRequestTimeout = 30 * time.Second
+
+// DaemonClient reaches the simulated API state shared by every provider
+// process, held by a "terraform-provider-testlagger daemon" listening on a
+// Unix socket.
+type DaemonClient struct {
+	http *http.Client
+}
+
+// DaemonHealth is the response of the daemon to a health check.
+type DaemonHealth struct {
+	Pid int `json:"pid"`
+}
+
+// DaemonCallCount is the response of the daemon to a counted call.
+type DaemonCallCount struct {
+	Count int64 `json:"count"`
+}
+
+// DaemonReserveRequest asks the daemon for a token from its rate limit.
+type DaemonReserveRequest struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int64   `json:"burst"`
+	Queue             bool    `json:"queue"`
+}
+
+// DaemonReservation is the response of the daemon to a reserve request, as
+// returned by TokenBucket.Reserve.
+type DaemonReservation struct {
+	WaitNs int64 `json:"wait_ns"`
+	Ok     bool  `json:"ok"`
+}
+
+// NewDaemonClient returns a client of the daemon listening on the Unix
+// socket at the given path. It does not connect until the first request.
+func NewDaemonClient(socket string) *DaemonClient {
+	var dialer net.Dialer
+
+	return &DaemonClient{
+		http: &http.Client{
+			Timeout: daemonRequestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// do sends a request to the daemon and decodes the JSON response into out,
+// unless out is nil. It returns the response status code, and an error for
+// any error status, including not found, which only Get expects.
+func (c *DaemonClient) do(ctx context.Context, method string, path string, in any, out any) (int, error) {
+	var body io.Reader
+
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+
+		body = bytes.NewReader(content)
+	}
+
+	// The host is ignored, as every request is sent to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("daemon responded %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Health checks that the daemon is responding.
+func (c *DaemonClient) Health(ctx context.Context) (DaemonHealth, error) {
+	var health DaemonHealth
+
+	_, err := c.do(ctx, http.MethodGet, "/v1/health", nil, &health)
+
+	return health, err
+}
+
+// CountCall records a call to the given lag point and returns the number of
+// calls made to it so far by every process, counting from 1.
+func (c *DaemonClient) CountCall(lagPoint string) (int64, error) {
+	var count DaemonCallCount
+
+	_, err := c.do(context.Background(), http.MethodPost, "/v1/calls/"+url.PathEscape(lagPoint), nil, &count)
+
+	return count.Count, err
+}
+
+// Reserve takes a token from the rate limit shared by every process, as
+// TokenBucket.Reserve does.
+func (c *DaemonClient) Reserve(requestsPerSecond float64, burst int64, queue bool) (time.Duration, bool, error) {
+	var reservation DaemonReservation
+
+	_, err := c.do(context.Background(), http.MethodPost, "/v1/reserve", DaemonReserveRequest{RequestsPerSecond: requestsPerSecond, Burst: burst, Queue: queue}, &reservation)
+
+	return time.Duration(reservation.WaitNs), reservation.Ok, err
+}
+
+func (c *DaemonClient) Get(id string) (*RemoteObject, error) {
+	var object RemoteObject
+
+	status, err := c.do(context.Background(), http.MethodGet, "/v1/objects/"+url.PathEscape(id), nil, &object)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (c *DaemonClient) Put(object RemoteObject) error {
+	_, err := c.do(context.Background(), http.MethodPut, "/v1/objects/"+url.PathEscape(object.Id), object, nil)
+
+	return err
+}
+
+func (c *DaemonClient) Delete(id string) error {
+	_, err := c.do(context.Background(), http.MethodDelete, "/v1/objects/"+url.PathEscape(id), nil, nil)
+
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Conditions under which resources and data sources are deferred.
+const (
+	// DeferWhenProviderConfigUnknown defers when the provider configuration
+	// has unknown values.
+	DeferWhenProviderConfigUnknown = "provider_config_unknown"
+
+	// DeferWhenResourceConfigUnknown defers when the configuration of the
+	// resource itself has unknown values. Data sources do not support it, as
+	// Terraform does not read them until their configuration is known.
+	DeferWhenResourceConfigUnknown = "resource_config_unknown"
+
+	// DeferWhenAlways always defers, as if a prerequisite were missing.
+	DeferWhenAlways = "always"
+)
+
+// deferWhenDescription describes the defer_when attribute of resources.
+var deferWhenDescription = fmt.Sprintf("Defer the resource to a later plan and apply when the provider configuration has unknown values (`%s`), when the configuration of the resource itself has unknown values (`%s`) or `%s`. Deferral is skipped unless Terraform allows it", DeferWhenProviderConfigUnknown, DeferWhenResourceConfigUnknown, DeferWhenAlways)
+
+// dataSourceDeferWhenDescription describes the defer_when attribute of data
+// sources, which cannot defer on their own configuration being unknown, as
+// Terraform does not read them until it is known.
+var dataSourceDeferWhenDescription = fmt.Sprintf("Defer the data source to a later plan and apply when the provider configuration has unknown values (`%s`) or `%s`. Deferral is skipped unless Terraform allows it. Unlike resources, `%s` is not accepted, as Terraform does not read a data source while its configuration has unknown values", DeferWhenProviderConfigUnknown, DeferWhenAlways, DeferWhenResourceConfigUnknown)
+
+// deferCondition returns the condition under which a resource or data source
+// with the given defer_when is deferred now, or "" when it is not. The
+// configuration of the resource or data source is nil when it is not known,
+// as when reading or importing a resource.
+func deferCondition(client *TestLaggerClient, deferWhen types.String, config *tfsdk.Config) string {
+	switch deferWhen.ValueString() {
+	case DeferWhenProviderConfigUnknown:
+		if client != nil && client.ConfigUnknown {
+			return DeferWhenProviderConfigUnknown
+		}
+	case DeferWhenResourceConfigUnknown:
+		if config != nil && !config.Raw.IsFullyKnown() {
+			return DeferWhenResourceConfigUnknown
+		}
+	case DeferWhenAlways:
+		return DeferWhenAlways
+	}
+
+	return ""
+}
+
+// resourceDeferred returns the deferral of a resource under the given
+// condition, or nil when it is not deferred.
+func resourceDeferred(condition string) *resource.Deferred {
+	switch condition {
+	case DeferWhenProviderConfigUnknown:
+		return &resource.Deferred{Reason: resource.DeferredReasonProviderConfigUnknown}
+	case DeferWhenResourceConfigUnknown:
+		return &resource.Deferred{Reason: resource.DeferredReasonResourceConfigUnknown}
+	case DeferWhenAlways:
+		return &resource.Deferred{Reason: resource.DeferredReasonAbsentPrereq}
+	}
+
+	return nil
+}
+
+// dataSourceDeferred returns the deferral of a data source under the given
+// condition, or nil when it is not deferred.
+func dataSourceDeferred(condition string) *datasource.Deferred {
+	switch condition {
+	case DeferWhenProviderConfigUnknown:
+		return &datasource.Deferred{Reason: datasource.DeferredReasonProviderConfigUnknown}
+	case DeferWhenAlways:
+		return &datasource.Deferred{Reason: datasource.DeferredReasonAbsentPrereq}
+	}
+
+	return nil
+}
+
+// validateDeferWhen checks the defer_when attribute of the configuration of a
+// resource.
+func validateDeferWhen(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var deferWhen types.String
+
+	diags := config.GetAttribute(ctx, path.Root("defer_when"), &deferWhen)
+
+	if diags.HasError() || deferWhen.IsNull() || deferWhen.IsUnknown() {
+		return diags
+	}
+
+	switch deferWhen.ValueString() {
+	case DeferWhenProviderConfigUnknown, DeferWhenResourceConfigUnknown, DeferWhenAlways:
+	default:
+		diags.AddAttributeError(
+			path.Root("defer_when"),
+			"Invalid Defer When",
+			fmt.Sprintf("Expected %s, %s or %s, got %q", DeferWhenProviderConfigUnknown, DeferWhenResourceConfigUnknown, DeferWhenAlways, deferWhen.ValueString()),
+		)
+	}
+
+	return diags
+}
+
+// validateDataSourceDeferWhen checks the defer_when attribute of the
+// configuration of a data source.
+func validateDataSourceDeferWhen(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var deferWhen types.String
+
+	diags := config.GetAttribute(ctx, path.Root("defer_when"), &deferWhen)
+
+	if diags.HasError() || deferWhen.IsNull() || deferWhen.IsUnknown() {
+		return diags
+	}
+
+	switch deferWhen.ValueString() {
+	case DeferWhenProviderConfigUnknown, DeferWhenAlways:
+	case DeferWhenResourceConfigUnknown:
+		diags.AddAttributeError(
+			path.Root("defer_when"),
+			"Invalid Defer When",
+			fmt.Sprintf("%s is not supported by data sources, as Terraform does not read a data source while its configuration has unknown values. Expected %s or %s", DeferWhenResourceConfigUnknown, DeferWhenProviderConfigUnknown, DeferWhenAlways),
+		)
+	default:
+		diags.AddAttributeError(
+			path.Root("defer_when"),
+			"Invalid Defer When",
+			fmt.Sprintf("Expected %s or %s, got %q", DeferWhenProviderConfigUnknown, DeferWhenAlways, deferWhen.ValueString()),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,399 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDeferCondition(t *testing.T) {
+	known := &tfsdk.Config{Raw: tftypes.NewValue(tftypes.String, "known")}
+	unknown := &tfsdk.Config{Raw: tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}
+
+	testCases := map[string]struct {
+		client    *TestLaggerClient
+		deferWhen types.String
+		config    *tfsdk.Config
+		want      string
+	}{
+		"null": {
+			client:    &TestLaggerClient{ConfigUnknown: true},
+			deferWhen: types.StringNull(),
+			config:    unknown,
+		},
+		"provider-config-known": {
+			client:    &TestLaggerClient{},
+			deferWhen: types.StringValue(DeferWhenProviderConfigUnknown),
+		},
+		"provider-config-unknown": {
+			client:    &TestLaggerClient{ConfigUnknown: true},
+			deferWhen: types.StringValue(DeferWhenProviderConfigUnknown),
+			want:      DeferWhenProviderConfigUnknown,
+		},
+		"resource-config-known": {
+			client:    &TestLaggerClient{ConfigUnknown: true},
+			deferWhen: types.StringValue(DeferWhenResourceConfigUnknown),
+			config:    known,
+		},
+		"resource-config-unknown": {
+			client:    &TestLaggerClient{},
+			deferWhen: types.StringValue(DeferWhenResourceConfigUnknown),
+			config:    unknown,
+			want:      DeferWhenResourceConfigUnknown,
+		},
+		"resource-config-not-given": {
+			client:    &TestLaggerClient{},
+			deferWhen: types.StringValue(DeferWhenResourceConfigUnknown),
+		},
+		"always": {
+			client:    &TestLaggerClient{},
+			deferWhen: types.StringValue(DeferWhenAlways),
+			want:      DeferWhenAlways,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := deferCondition(testCase.client, testCase.deferWhen, testCase.config); got != testCase.want {
+				t.Fatalf("expected condition %q, got %q", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestLagResource_ReadDeferred(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	// Applies are never deferred
+	created, errors := testCreateLagResource(t, server, stateType, map[string]tftypes.Value{
+		"input":      tftypes.NewValue(tftypes.String, "one"),
+		"defer_when": tftypes.NewValue(tftypes.String, DeferWhenAlways),
+	})
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	for name, deferralAllowed := range map[string]bool{"allowed": true, "not-allowed": false} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+				TypeName:           "testlagger_lag",
+				CurrentState:       created.NewState,
+				Private:            created.Private,
+				ClientCapabilities: &tfprotov6.ReadResourceClientCapabilities{DeferralAllowed: deferralAllowed},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, diagnostic := range resp.Diagnostics {
+				t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			if deferralAllowed && (resp.Deferred == nil || resp.Deferred.Reason != tfprotov6.DeferredReasonAbsentPrereq) {
+				t.Errorf("expected the read to be deferred for an absent prerequisite, got %v", resp.Deferred)
+			}
+
+			if !deferralAllowed && resp.Deferred != nil {
+				t.Errorf("expected the read not to be deferred, got %v", resp.Deferred)
+			}
+		})
+	}
+}
+
+func TestLagDataSource_ReadDeferred(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	configType := schemaResp.DataSourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range configType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+	attributes["defer_when"] = tftypes.NewValue(tftypes.String, DeferWhenAlways)
+
+	config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.ReadDataSource(context.Background(), &tfprotov6.ReadDataSourceRequest{
+		TypeName:           "testlagger_lag",
+		Config:             &config,
+		ClientCapabilities: &tfprotov6.ReadDataSourceClientCapabilities{DeferralAllowed: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	if resp.Deferred == nil || resp.Deferred.Reason != tfprotov6.DeferredReasonAbsentPrereq {
+		t.Fatalf("expected the read to be deferred for an absent prerequisite, got %v", resp.Deferred)
+	}
+
+	state, err := resp.State.Unmarshal(configType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.IsKnown() {
+		t.Errorf("expected the deferred state to be unknown, got %s", state)
+	}
+}
+
+func TestLagDataSource_ValidateDeferWhen(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	configType := schemaResp.DataSourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	testCases := map[string]struct {
+		deferWhen string
+		want      string
+	}{
+		"provider-config-unknown": {deferWhen: DeferWhenProviderConfigUnknown},
+		"always":                  {deferWhen: DeferWhenAlways},
+		"resource-config-unknown": {deferWhen: DeferWhenResourceConfigUnknown, want: "Invalid Defer When"},
+		"unsupported":             {deferWhen: "sometimes", want: "Invalid Defer When"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range configType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+			attributes["defer_when"] = tftypes.NewValue(tftypes.String, testCase.deferWhen)
+
+			config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.ValidateDataResourceConfig(context.Background(), &tfprotov6.ValidateDataResourceConfigRequest{TypeName: "testlagger_lag", Config: &config})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if testCase.want == "" && len(resp.Diagnostics) > 0 {
+				t.Errorf("unexpected diagnostic %s: %s", resp.Diagnostics[0].Summary, resp.Diagnostics[0].Detail)
+			}
+
+			if testCase.want != "" && (len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Summary != testCase.want) {
+				t.Errorf("expected %q, got %v", testCase.want, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+// testDeferAllProviderServer returns a provider server configured with
+// defer_all, and the response to configuring it.
+func testDeferAllProviderServer(t *testing.T, deferralAllowed bool) (tfprotov6.ProviderServer, *tfprotov6.GetProviderSchemaResponse, *tfprotov6.ConfigureProviderResponse) {
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objectType := schemaResp.Provider.ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	attributes["defer_all"] = tftypes.NewValue(tftypes.Bool, true)
+
+	config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{
+		Config:             &config,
+		ClientCapabilities: &tfprotov6.ConfigureProviderClientCapabilities{DeferralAllowed: deferralAllowed},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, schemaResp, resp
+}
+
+func TestProvider_DeferAll(t *testing.T) {
+	for name, deferralAllowed := range map[string]bool{"allowed": true, "not-allowed": false} {
+		t.Run(name, func(t *testing.T) {
+			server, schemaResp, resp := testDeferAllProviderServer(t, deferralAllowed)
+
+			if deferralAllowed {
+				for _, diagnostic := range resp.Diagnostics {
+					t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+				}
+
+				// Data sources are deferred without being read
+				dataSourceType := schemaResp.DataSourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+				dataSourceAttributes := map[string]tftypes.Value{}
+				for name, attributeType := range dataSourceType.AttributeTypes {
+					dataSourceAttributes[name] = tftypes.NewValue(attributeType, nil)
+				}
+
+				dataSourceAttributes["input"] = tftypes.NewValue(tftypes.String, "one")
+
+				dataSourceConfig, err := tfprotov6.NewDynamicValue(dataSourceType, tftypes.NewValue(dataSourceType, dataSourceAttributes))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				readResp, err := server.ReadDataSource(context.Background(), &tfprotov6.ReadDataSourceRequest{
+					TypeName:           "testlagger_lag",
+					Config:             &dataSourceConfig,
+					ClientCapabilities: &tfprotov6.ReadDataSourceClientCapabilities{DeferralAllowed: true},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if readResp.Deferred == nil || readResp.Deferred.Reason != tfprotov6.DeferredReasonProviderConfigUnknown {
+					t.Errorf("expected the read to be deferred for the provider configuration, got %v", readResp.Deferred)
+				}
+
+				return
+			}
+
+			if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Severity != tfprotov6.DiagnosticSeverityWarning {
+				t.Fatalf("expected a warning that defer_all is ignored, got %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestLagResource_PlanDeferred(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range stateType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	attributes["input"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	attributes["defer_when"] = tftypes.NewValue(tftypes.String, DeferWhenResourceConfigUnknown)
+
+	config, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priorState, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:           "testlagger_lag",
+		PriorState:         &priorState,
+		ProposedNewState:   &config,
+		Config:             &config,
+		ClientCapabilities: &tfprotov6.PlanResourceChangeClientCapabilities{DeferralAllowed: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	if resp.Deferred == nil || resp.Deferred.Reason != tfprotov6.DeferredReasonResourceConfigUnknown {
+		t.Errorf("expected the plan to be deferred for its unknown configuration, got %v", resp.Deferred)
+	}
+}
+
+func TestProvider_DeferAllPlanModification(t *testing.T) {
+	server, schemaResp, _ := testDeferAllProviderServer(t, true)
+
+	for _, typeName := range []string{"testlagger_lag", "testlagger_lag_v2"} {
+		t.Run(typeName, func(t *testing.T) {
+			stateType := schemaResp.ResourceSchemas[typeName].ValueType().(tftypes.Object)
+			keysType := stateType.AttributeTypes["computed_output_keys"]
+
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range stateType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+			attributes["computed_output_keys"] = tftypes.NewValue(keysType, []tftypes.Value{
+				tftypes.NewValue(tftypes.String, "a"),
+			})
+
+			config, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			priorState, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+				TypeName:           typeName,
+				PriorState:         &priorState,
+				ProposedNewState:   &config,
+				Config:             &config,
+				ClientCapabilities: &tfprotov6.PlanResourceChangeClientCapabilities{DeferralAllowed: true},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, diagnostic := range resp.Diagnostics {
+				t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			if resp.Deferred == nil || resp.Deferred.Reason != tfprotov6.DeferredReasonProviderConfigUnknown {
+				t.Errorf("expected the plan to be deferred for the provider configuration, got %v", resp.Deferred)
+			}
+
+			planned, err := resp.PlannedState.Unmarshal(stateType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var plannedAttributes map[string]tftypes.Value
+			if err := planned.As(&plannedAttributes); err != nil {
+				t.Fatal(err)
+			}
+
+			// The keys of computed_outputs are only known when the plan is modified
+			var outputs map[string]tftypes.Value
+			if err := plannedAttributes["computed_outputs"].As(&outputs); err != nil || len(outputs) != 1 {
+				t.Errorf("expected the plan to be modified while deferred, got computed_outputs %s", plannedAttributes["computed_outputs"])
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	ephemeralschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Supported delay distributions.
+const (
+	DelayDistributionFixed       = "fixed"
+	DelayDistributionUniform     = "uniform"
+	DelayDistributionNormal      = "normal"
+	DelayDistributionExponential = "exponential"
+	DelayDistributionLogNormal   = "lognormal"
+)
+
+var delayDistributions = []string{
+	DelayDistributionFixed,
+	DelayDistributionUniform,
+	DelayDistributionNormal,
+	DelayDistributionExponential,
+	DelayDistributionLogNormal,
+}
+
+// DelaySpec describes how long a lag point sleeps for. All values are in
+// milliseconds.
+//
+//   - fixed: always Value.
+//   - uniform: uniformly distributed between Min and Max.
+//   - normal: normally distributed with Mean and StdDev.
+//   - exponential: exponentially distributed with Mean.
+//   - lognormal: log-normally distributed with Mean and StdDev.
+//
+// Min and Max, when set, bound the sampled value of every distribution other
+// than fixed. Samples are never negative.
+type DelaySpec struct {
+	Distribution string
+	Value        int64
+	Min          *int64
+	Max          *int64
+	Mean         int64
+	StdDev       int64
+	Seed         *int64
+}
+
+// FixedDelay returns a DelaySpec that always sleeps for the given number of
+// milliseconds.
+func FixedDelay(delay int64) DelaySpec {
+	return DelaySpec{
+		Distribution: DelayDistributionFixed,
+		Value:        delay,
+	}
+}
+
+// Validate checks that the parameters required by the distribution are
+// present and sensible.
+func (s DelaySpec) Validate() error {
+	switch s.Distribution {
+	case DelayDistributionFixed:
+		if s.Value < 0 {
+			return fmt.Errorf("fixed distribution value must not be negative, got %d", s.Value)
+		}
+	case DelayDistributionUniform:
+		if s.Min == nil || s.Max == nil {
+			return fmt.Errorf("uniform distribution requires both min and max")
+		}
+	case DelayDistributionNormal, DelayDistributionLogNormal:
+		if s.Mean < 0 || s.StdDev < 0 {
+			return fmt.Errorf("%s distribution mean and stddev must not be negative", s.Distribution)
+		}
+	case DelayDistributionExponential:
+		if s.Mean < 0 {
+			return fmt.Errorf("exponential distribution mean must not be negative, got %d", s.Mean)
+		}
+	default:
+		return fmt.Errorf("unknown distribution %q, expected one of: %s", s.Distribution, strings.Join(delayDistributions, ", "))
+	}
+
+	if s.Min != nil && s.Max != nil && *s.Min > *s.Max {
+		return fmt.Errorf("min (%d) must not be greater than max (%d)", *s.Min, *s.Max)
+	}
+
+	return nil
+}
+
+// Sample returns the number of milliseconds to sleep for. The key identifies
+// the lag point being sampled; when a seed is set, the sequence of samples
+// for a given seed and key is the same on every run.
+func (s DelaySpec) Sample(key string) int64 {
+	if s.Distribution == DelayDistributionFixed || s.Distribution == "" {
+		return max(s.Value, 0)
+	}
+
+	var sample float64
+
+	defaultSampler.sample(s.Seed, key, func(r *rand.Rand) {
+		switch s.Distribution {
+		case DelayDistributionUniform:
+			lo, hi := float64(*s.Min), float64(*s.Max)
+			sample = lo + r.Float64()*(hi-lo)
+		case DelayDistributionNormal:
+			sample = float64(s.Mean) + r.NormFloat64()*float64(s.StdDev)
+		case DelayDistributionExponential:
+			sample = r.ExpFloat64() * float64(s.Mean)
+		case DelayDistributionLogNormal:
+			// Derive the parameters of the underlying normal distribution so
+			// that the delay itself has the requested mean and stddev.
+			if s.Mean == 0 {
+				sample = 0
+				break
+			}
+			mean, stdDev := float64(s.Mean), float64(s.StdDev)
+			sigma := math.Sqrt(math.Log(1 + (stdDev*stdDev)/(mean*mean)))
+			mu := math.Log(mean) - sigma*sigma/2
+			sample = math.Exp(mu + r.NormFloat64()*sigma)
+		}
+	})
+
+	if s.Min != nil {
+		sample = math.Max(sample, float64(*s.Min))
+	}
+
+	if s.Max != nil {
+		sample = math.Min(sample, float64(*s.Max))
+	}
+
+	return max(int64(math.Round(sample)), 0)
+}
+
+// String returns a short human readable description of the spec.
+func (s DelaySpec) String() string {
+	switch s.Distribution {
+	case DelayDistributionUniform:
+		return fmt.Sprintf("uniform(%d,%d)", *s.Min, *s.Max)
+	case DelayDistributionNormal, DelayDistributionLogNormal:
+		return fmt.Sprintf("%s(%d,%d)", s.Distribution, s.Mean, s.StdDev)
+	case DelayDistributionExponential:
+		return fmt.Sprintf("exponential(%d)", s.Mean)
+	default:
+		return fmt.Sprintf("fixed(%d)", s.Value)
+	}
+}
+
+// maxDelaySamplerCounts is the number of seeded lag points whose sample counts
+// a delaySampler keeps, after which it forgets them all.
+const maxDelaySamplerCounts = 4096
+
+// delaySampler hands out random streams for delay sampling. Seeded streams are
+// derived from the seed, the lag point and the number of samples taken so far
+// at it, so that concurrently sampled lag points do not disturb each others
+// sequences. Only the counts are kept, rather than the streams themselves, and
+// at most maxDelaySamplerCounts of them. Once there are more, every sequence
+// starts over, as it would in a new provider process.
+type delaySampler struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+var defaultSampler = &delaySampler{
+	counts: map[string]uint64{},
+}
+
+func (d *delaySampler) sample(seed *int64, key string, fn func(r *rand.Rand)) {
+	if seed == nil {
+		fn(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+		return
+	}
+
+	streamKey := fmt.Sprintf("%d/%s", *seed, key)
+
+	d.mu.Lock()
+	count, ok := d.counts[streamKey]
+	if !ok && len(d.counts) >= maxDelaySamplerCounts {
+		clear(d.counts)
+	}
+	d.counts[streamKey] = count + 1
+	d.mu.Unlock()
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte(fmt.Sprintf("/%d", count)))
+
+	fn(rand.New(rand.NewPCG(uint64(*seed), h.Sum64())))
+}
+
+// DelayDistributionModel describes the delay distribution data model.
+type DelayDistributionModel struct {
+	Distribution types.String `tfsdk:"distribution"`
+	Value        types.Int64  `tfsdk:"value"`
+	Min          types.Int64  `tfsdk:"min"`
+	Max          types.Int64  `tfsdk:"max"`
+	Mean         types.Int64  `tfsdk:"mean"`
+	StdDev       types.Int64  `tfsdk:"stddev"`
+	Seed         types.Int64  `tfsdk:"seed"`
+}
+
+// delayDistributionObjectType is the type of a single delay distribution.
+var delayDistributionObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"distribution": types.StringType,
+		"value":        types.Int64Type,
+		"min":          types.Int64Type,
+		"max":          types.Int64Type,
+		"mean":         types.Int64Type,
+		"stddev":       types.Int64Type,
+		"seed":         types.Int64Type,
+	},
+}
+
+const (
+	delayDistributionsDescription = "Delay distributions keyed by lag point, overriding the fixed delay for that lag point. Valid keys are: "
+	delayDistributionDescription  = "Delay distribution, one of `fixed`, `uniform`, `normal`, `exponential` or `lognormal`"
+	delayValueDescription         = "Amount of time in milliseconds to delay for the `fixed` distribution"
+	delayMinDescription           = "Minimum amount of time in milliseconds to delay. Required for the `uniform` distribution"
+	delayMaxDescription           = "Maximum amount of time in milliseconds to delay. Required for the `uniform` distribution"
+	delayMeanDescription          = "Mean amount of time in milliseconds to delay for the `normal`, `exponential` and `lognormal` distributions"
+	delayStdDevDescription        = "Standard deviation in milliseconds of the delay for the `normal` and `lognormal` distributions"
+	delaySeedDescription          = "Seed for the random number generator, making the sequence of delays repeatable between runs"
+)
+
+func resourceDelayDistributionsAttribute(keys ...string) resourceschema.MapNestedAttribute {
+	return resourceschema.MapNestedAttribute{
+		MarkdownDescription: delayDistributionsDescription + quoteKeys(keys),
+		Optional:            true,
+		NestedObject: resourceschema.NestedAttributeObject{
+			Attributes: map[string]resourceschema.Attribute{
+				"distribution": resourceschema.StringAttribute{MarkdownDescription: delayDistributionDescription, Required: true},
+				"value":        resourceschema.Int64Attribute{MarkdownDescription: delayValueDescription, Optional: true},
+				"min":          resourceschema.Int64Attribute{MarkdownDescription: delayMinDescription, Optional: true},
+				"max":          resourceschema.Int64Attribute{MarkdownDescription: delayMaxDescription, Optional: true},
+				"mean":         resourceschema.Int64Attribute{MarkdownDescription: delayMeanDescription, Optional: true},
+				"stddev":       resourceschema.Int64Attribute{MarkdownDescription: delayStdDevDescription, Optional: true},
+				"seed":         resourceschema.Int64Attribute{MarkdownDescription: delaySeedDescription, Optional: true},
+			},
+		},
+	}
+}
+
+func dataSourceDelayDistributionsAttribute(keys ...string) datasourceschema.MapNestedAttribute {
+	return datasourceschema.MapNestedAttribute{
+		MarkdownDescription: delayDistributionsDescription + quoteKeys(keys),
+		Optional:            true,
+		NestedObject: datasourceschema.NestedAttributeObject{
+			Attributes: map[string]datasourceschema.Attribute{
+				"distribution": datasourceschema.StringAttribute{MarkdownDescription: delayDistributionDescription, Required: true},
+				"value":        datasourceschema.Int64Attribute{MarkdownDescription: delayValueDescription, Optional: true},
+				"min":          datasourceschema.Int64Attribute{MarkdownDescription: delayMinDescription, Optional: true},
+				"max":          datasourceschema.Int64Attribute{MarkdownDescription: delayMaxDescription, Optional: true},
+				"mean":         datasourceschema.Int64Attribute{MarkdownDescription: delayMeanDescription, Optional: true},
+				"stddev":       datasourceschema.Int64Attribute{MarkdownDescription: delayStdDevDescription, Optional: true},
+				"seed":         datasourceschema.Int64Attribute{MarkdownDescription: delaySeedDescription, Optional: true},
+			},
+		},
+	}
+}
+
+func ephemeralDelayDistributionsAttribute(keys ...string) ephemeralschema.MapNestedAttribute {
+	return ephemeralschema.MapNestedAttribute{
+		MarkdownDescription: delayDistributionsDescription + quoteKeys(keys),
+		Optional:            true,
+		NestedObject: ephemeralschema.NestedAttributeObject{
+			Attributes: map[string]ephemeralschema.Attribute{
+				"distribution": ephemeralschema.StringAttribute{MarkdownDescription: delayDistributionDescription, Required: true},
+				"value":        ephemeralschema.Int64Attribute{MarkdownDescription: delayValueDescription, Optional: true},
+				"min":          ephemeralschema.Int64Attribute{MarkdownDescription: delayMinDescription, Optional: true},
+				"max":          ephemeralschema.Int64Attribute{MarkdownDescription: delayMaxDescription, Optional: true},
+				"mean":         ephemeralschema.Int64Attribute{MarkdownDescription: delayMeanDescription, Optional: true},
+				"stddev":       ephemeralschema.Int64Attribute{MarkdownDescription: delayStdDevDescription, Optional: true},
+				"seed":         ephemeralschema.Int64Attribute{MarkdownDescription: delaySeedDescription, Optional: true},
+			},
+		},
+	}
+}
+
+func listDelayDistributionsAttribute(keys ...string) listschema.MapNestedAttribute {
+	return listschema.MapNestedAttribute{
+		MarkdownDescription: delayDistributionsDescription + quoteKeys(keys),
+		Optional:            true,
+		NestedObject: listschema.NestedAttributeObject{
+			Attributes: map[string]listschema.Attribute{
+				"distribution": listschema.StringAttribute{MarkdownDescription: delayDistributionDescription, Required: true},
+				"value":        listschema.Int64Attribute{MarkdownDescription: delayValueDescription, Optional: true},
+				"min":          listschema.Int64Attribute{MarkdownDescription: delayMinDescription, Optional: true},
+				"max":          listschema.Int64Attribute{MarkdownDescription: delayMaxDescription, Optional: true},
+				"mean":         listschema.Int64Attribute{MarkdownDescription: delayMeanDescription, Optional: true},
+				"stddev":       listschema.Int64Attribute{MarkdownDescription: delayStdDevDescription, Optional: true},
+				"seed":         listschema.Int64Attribute{MarkdownDescription: delaySeedDescription, Optional: true},
+			},
+		},
+	}
+}
+
+func providerDelayDistributionsAttribute(keys ...string) providerschema.MapNestedAttribute {
+	return providerschema.MapNestedAttribute{
+		MarkdownDescription: delayDistributionsDescription + quoteKeys(keys),
+		Optional:            true,
+		NestedObject: providerschema.NestedAttributeObject{
+			Attributes: map[string]providerschema.Attribute{
+				"distribution": providerschema.StringAttribute{MarkdownDescription: delayDistributionDescription, Required: true},
+				"value":        providerschema.Int64Attribute{MarkdownDescription: delayValueDescription, Optional: true},
+				"min":          providerschema.Int64Attribute{MarkdownDescription: delayMinDescription, Optional: true},
+				"max":          providerschema.Int64Attribute{MarkdownDescription: delayMaxDescription, Optional: true},
+				"mean":         providerschema.Int64Attribute{MarkdownDescription: delayMeanDescription, Optional: true},
+				"stddev":       providerschema.Int64Attribute{MarkdownDescription: delayStdDevDescription, Optional: true},
+				"seed":         providerschema.Int64Attribute{MarkdownDescription: delaySeedDescription, Optional: true},
+			},
+		},
+	}
+}
+
+func quoteKeys(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = "`" + key + "`"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// DelaySpec converts the model into a DelaySpec.
+func (m DelayDistributionModel) DelaySpec() (DelaySpec, error) {
+	spec := DelaySpec{
+		Distribution: m.Distribution.ValueString(),
+		Value:        m.Value.ValueInt64(),
+		Mean:         m.Mean.ValueInt64(),
+		StdDev:       m.StdDev.ValueInt64(),
+		Min:          m.Min.ValueInt64Pointer(),
+		Max:          m.Max.ValueInt64Pointer(),
+		Seed:         m.Seed.ValueInt64Pointer(),
+	}
+
+	return spec, spec.Validate()
+}
+
+// delaySpecs converts a map of delay distributions into DelaySpecs, checking
+// that only the allowed lag points have been configured.
+func delaySpecs(ctx context.Context, distributions types.Map, keys ...string) (map[string]DelaySpec, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	specs := map[string]DelaySpec{}
+
+	if distributions.IsNull() || distributions.IsUnknown() {
+		return specs, diags
+	}
+
+	var models map[string]DelayDistributionModel
+	diags.Append(distributions.ElementsAs(ctx, &models, false)...)
+
+	if diags.HasError() {
+		return specs, diags
+	}
+
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if !slices.Contains(keys, name) {
+			diags.AddError(
+				"Invalid Delay Distribution",
+				fmt.Sprintf("Unknown lag point %q, expected one of: %s", name, strings.Join(keys, ", ")),
+			)
+			continue
+		}
+
+		spec, err := models[name].DelaySpec()
+		if err != nil {
+			diags.AddError(
+				"Invalid Delay Distribution",
+				fmt.Sprintf("Delay distribution for %q is invalid: %s", name, err),
+			)
+			continue
+		}
+
+		specs[name] = spec
+	}
+
+	return specs, diags
+}
+
+// resolveDelay returns the DelaySpec for a lag point. A configured delay
+// distribution takes precedence over the fixed delay.
+func resolveDelay(specs map[string]DelaySpec, name string, fixed types.Int64) DelaySpec {
+	if spec, ok := specs[name]; ok {
+		return spec
+	}
+
+	if fixed.IsNull() || fixed.IsUnknown() {
+		return FixedDelay(0)
+	}
+
+	return FixedDelay(fixed.ValueInt64())
+}
+
+// resolveDelayOrDefault returns the DelaySpec for a lag point like
+// resolveDelay, falling back to fallback when neither a delay distribution nor
+// a fixed delay has been configured.
+func resolveDelayOrDefault(specs map[string]DelaySpec, name string, fixed types.Int64, fallback DelaySpec) DelaySpec {
+	if _, ok := specs[name]; !ok && (fixed.IsNull() || fixed.IsUnknown()) {
+		return fallback
+	}
+
+	return resolveDelay(specs, name, fixed)
+}
+
+// delaySpecFromDynamic converts a function argument that is either a number of
+// milliseconds or a delay distribution object into a DelaySpec.
+func delaySpecFromDynamic(value types.Dynamic) (DelaySpec, error) {
+	switch v := value.UnderlyingValue().(type) {
+	case basetypes.NumberValue:
+		delay, _ := v.ValueBigFloat().Int64()
+		return FixedDelay(delay), nil
+	case basetypes.Int64Value:
+		return FixedDelay(v.ValueInt64()), nil
+	case basetypes.ObjectValue:
+		return delaySpecFromAttributes(v.Attributes())
+	case basetypes.MapValue:
+		return delaySpecFromAttributes(v.Elements())
+	default:
+		return DelaySpec{}, fmt.Errorf("delay must be a number or a delay distribution object, got %s", value.UnderlyingValue().Type(context.Background()))
+	}
+}
+
+func delaySpecFromAttributes(attributes map[string]attr.Value) (DelaySpec, error) {
+	var spec DelaySpec
+
+	for name, value := range attributes {
+		if value.IsNull() {
+			continue
+		}
+
+		if name == "distribution" {
+			distribution, ok := value.(basetypes.StringValue)
+			if !ok {
+				return spec, fmt.Errorf("distribution must be a string")
+			}
+			spec.Distribution = distribution.ValueString()
+			continue
+		}
+
+		number, ok := value.(basetypes.NumberValue)
+		if !ok {
+			return spec, fmt.Errorf("%s must be a number", name)
+		}
+		n, _ := number.ValueBigFloat().Int64()
+
+		switch name {
+		case "value":
+			spec.Value = n
+		case "min":
+			spec.Min = &n
+		case "max":
+			spec.Max = &n
+		case "mean":
+			spec.Mean = n
+		case "stddev":
+			spec.StdDev = n
+		case "seed":
+			spec.Seed = &n
+		default:
+			return spec, fmt.Errorf("unsupported delay distribution attribute %q", name)
+		}
+	}
+
+	if spec.Distribution == "" {
+		spec.Distribution = DelayDistributionFixed
+	}
+
+	return spec, spec.Validate()
+}
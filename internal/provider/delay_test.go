@@ -0,0 +1,163 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func int64Pointer(v int64) *int64 {
+	return &v
+}
+
+func TestDelaySpec_Validate(t *testing.T) {
+	testCases := map[string]struct {
+		spec    DelaySpec
+		wantErr bool
+	}{
+		"fixed":                     {spec: FixedDelay(100)},
+		"fixed-negative":            {spec: FixedDelay(-1), wantErr: true},
+		"uniform":                   {spec: DelaySpec{Distribution: DelayDistributionUniform, Min: int64Pointer(10), Max: int64Pointer(20)}},
+		"uniform-missing-max":       {spec: DelaySpec{Distribution: DelayDistributionUniform, Min: int64Pointer(10)}, wantErr: true},
+		"uniform-min-above-max":     {spec: DelaySpec{Distribution: DelayDistributionUniform, Min: int64Pointer(20), Max: int64Pointer(10)}, wantErr: true},
+		"normal":                    {spec: DelaySpec{Distribution: DelayDistributionNormal, Mean: 100, StdDev: 10}},
+		"normal-negative-stddev":    {spec: DelaySpec{Distribution: DelayDistributionNormal, Mean: 100, StdDev: -10}, wantErr: true},
+		"exponential":               {spec: DelaySpec{Distribution: DelayDistributionExponential, Mean: 100}},
+		"lognormal":                 {spec: DelaySpec{Distribution: DelayDistributionLogNormal, Mean: 100, StdDev: 50}},
+		"unknown-distribution":      {spec: DelaySpec{Distribution: "pareto"}, wantErr: true},
+		"exponential-negative-mean": {spec: DelaySpec{Distribution: DelayDistributionExponential, Mean: -1}, wantErr: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := testCase.spec.Validate()
+
+			if testCase.wantErr && err == nil {
+				t.Fatal("expected error, got none")
+			}
+
+			if !testCase.wantErr && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestDelaySpec_SampleBounds(t *testing.T) {
+	specs := []DelaySpec{
+		{Distribution: DelayDistributionUniform, Min: int64Pointer(100), Max: int64Pointer(200)},
+		{Distribution: DelayDistributionNormal, Mean: 150, StdDev: 100, Min: int64Pointer(100), Max: int64Pointer(200)},
+		{Distribution: DelayDistributionExponential, Mean: 150, Min: int64Pointer(100), Max: int64Pointer(200)},
+		{Distribution: DelayDistributionLogNormal, Mean: 150, StdDev: 100, Min: int64Pointer(100), Max: int64Pointer(200)},
+	}
+
+	for _, spec := range specs {
+		t.Run(spec.String(), func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				sample := spec.Sample("bounds")
+				if sample < 100 || sample > 200 {
+					t.Fatalf("sample %d out of bounds [100, 200]", sample)
+				}
+			}
+		})
+	}
+}
+
+func TestDelaySpec_SampleNeverNegative(t *testing.T) {
+	spec := DelaySpec{Distribution: DelayDistributionNormal, Mean: 0, StdDev: 1000}
+
+	for i := 0; i < 1000; i++ {
+		if sample := spec.Sample("negative"); sample < 0 {
+			t.Fatalf("sample %d is negative", sample)
+		}
+	}
+}
+
+func TestDelaySpec_SampleSeeded(t *testing.T) {
+	spec := DelaySpec{Distribution: DelayDistributionNormal, Mean: 1000, StdDev: 200, Seed: int64Pointer(42)}
+
+	sample := func(sampler *delaySampler, key string) []int64 {
+		original := defaultSampler
+		defaultSampler = sampler
+		defer func() { defaultSampler = original }()
+
+		samples := make([]int64, 10)
+		for i := range samples {
+			samples[i] = spec.Sample(key)
+		}
+
+		return samples
+	}
+
+	sampler := &delaySampler{counts: map[string]uint64{}}
+
+	first := sample(sampler, "seeded")
+	second := sample(&delaySampler{counts: map[string]uint64{}}, "seeded")
+	other := sample(&delaySampler{counts: map[string]uint64{}}, "other")
+
+	if !slices.Equal(first, second) {
+		t.Fatalf("expected seeded samples to repeat, got %v and %v", first, second)
+	}
+
+	if slices.Equal(first, other) {
+		t.Fatalf("expected samples for different lag points to differ, got %v", first)
+	}
+
+	if slices.Equal(first[:5], first[5:]) {
+		t.Fatalf("expected successive samples to differ, got %v", first)
+	}
+
+	if len(sampler.counts) != 1 || sampler.counts["42/seeded"] != 10 {
+		t.Fatalf("expected only the count of the seeded lag point to be kept, got %v", sampler.counts)
+	}
+
+	// Sampling as many other lag points as are kept forgets the seeded one,
+	// whose sequence starts over
+	for i := range maxDelaySamplerCounts {
+		sample(sampler, fmt.Sprintf("other-%d", i))
+	}
+
+	if len(sampler.counts) > maxDelaySamplerCounts {
+		t.Fatalf("expected at most %d counts to be kept, got %d", maxDelaySamplerCounts, len(sampler.counts))
+	}
+
+	if again := sample(sampler, "seeded"); !slices.Equal(first, again) {
+		t.Fatalf("expected a forgotten lag point to start over, got %v and %v", first, again)
+	}
+}
+
+func TestResolveDelayOrDefault(t *testing.T) {
+	fallback := FixedDelay(300)
+	specs := map[string]DelaySpec{
+		"plan": {Distribution: DelayDistributionExponential, Mean: 100},
+	}
+
+	testCases := map[string]struct {
+		specs map[string]DelaySpec
+		fixed types.Int64
+		want  DelaySpec
+	}{
+		"default":      {fixed: types.Int64Null(), want: fallback},
+		"unknown":      {fixed: types.Int64Unknown(), want: fallback},
+		"fixed":        {fixed: types.Int64Value(100), want: FixedDelay(100)},
+		"fixed-zero":   {fixed: types.Int64Value(0), want: FixedDelay(0)},
+		"distribution": {specs: specs, fixed: types.Int64Value(100), want: specs["plan"]},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := resolveDelayOrDefault(testCase.specs, "plan", testCase.fixed, fallback)
+
+			if got.String() != testCase.want.String() {
+				t.Fatalf("expected %s, got %s", testCase.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &DriftResource{}
+var _ resource.ResourceWithConfigure = &DriftResource{}
+var _ resource.ResourceWithValidateConfig = &DriftResource{}
+
+// Drift actions.
+const (
+	DriftActionUpdate = "update"
+	DriftActionDelete = "delete"
+)
+
+func NewDriftResource() resource.Resource {
+	return &DriftResource{}
+}
+
+// DriftResource changes or deletes the remote object of a lag resource
+// out-of-band, so that the next refresh of the lag resource finds drift.
+type DriftResource struct {
+	client *TestLaggerClient
+}
+
+type driftResourceModel struct {
+	Id       types.String `tfsdk:"id"`
+	TargetId types.String `tfsdk:"target_id"`
+	Action   types.String `tfsdk:"action"`
+	Input    types.String `tfsdk:"input"`
+	Output   types.String `tfsdk:"output"`
+}
+
+func (r *DriftResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_drift"
+}
+
+func (r *DriftResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Changes or deletes the remote object of a `testlagger_lag` when created or updated, so that its next refresh finds drift. Requires the provider `remote_store_path` or `endpoint`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Id of the changed remote object",
+				Computed:            true,
+			},
+			"target_id": schema.StringAttribute{
+				MarkdownDescription: "Id of the `testlagger_lag` whose remote object is changed",
+				Required:            true,
+			},
+			"action": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("`%s` to change the input and output of the remote object, or `%s` to delete it. Defaults to `%s`", DriftActionUpdate, DriftActionDelete, DriftActionUpdate),
+				Optional:            true,
+			},
+			"input": schema.StringAttribute{
+				MarkdownDescription: "Input to give the remote object when updating it",
+				Optional:            true,
+			},
+			"output": schema.StringAttribute{
+				MarkdownDescription: "Output to give the remote object when updating it",
+				Optional:            true,
+			},
+		},
+	}
+}
+
+func (r *DriftResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*TestLaggerClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TestLaggerClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *DriftResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var action types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("action"), &action)...)
+
+	if resp.Diagnostics.HasError() || action.IsNull() || action.IsUnknown() {
+		return
+	}
+
+	if action.ValueString() != DriftActionUpdate && action.ValueString() != DriftActionDelete {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("action"),
+			"Invalid Drift Action",
+			fmt.Sprintf("Expected %s or %s, got %q", DriftActionUpdate, DriftActionDelete, action.ValueString()),
+		)
+	}
+}
+
+func (r *DriftResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data driftResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.drift(data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = data.TargetId
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Read keeps the state, as the drift has already been applied.
+func (r *DriftResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+func (r *DriftResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data driftResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.drift(data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = data.TargetId
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Delete only removes the resource from state, as drift cannot be undone.
+func (r *DriftResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// drift changes or deletes the target remote object.
+func (r *DriftResource) drift(data driftResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.client == nil || r.client.RemoteStore == nil {
+		diags.AddError(
+			"Remote Store Not Configured",
+			"testlagger_drift changes the remote store, which requires the provider remote_store_path or endpoint to be set.",
+		)
+
+		return diags
+	}
+
+	defer r.client.Concurrency.Start("resource_drift")()
+
+	id := data.TargetId.ValueString()
+
+	if data.Action.ValueString() == DriftActionDelete {
+		if err := r.client.RemoteStore.Delete(id); err != nil {
+			diags.AddError("Unable to Delete Remote Object", fmt.Sprintf("Unable to delete remote object %q: %s", id, err))
+		}
+
+		return diags
+	}
+
+	object, err := r.client.RemoteStore.Get(id)
+	if err != nil {
+		diags.AddError("Unable to Read Remote Object", fmt.Sprintf("Unable to read remote object %q: %s", id, err))
+		return diags
+	}
+
+	if object == nil {
+		diags.AddAttributeError(path.Root("target_id"), "Remote Object Not Found", fmt.Sprintf("There is no remote object %q to change", id))
+		return diags
+	}
+
+	if !data.Input.IsNull() {
+		object.Input = data.Input.ValueString()
+	}
+
+	if !data.Output.IsNull() {
+		object.Output = data.Output.ValueString()
+	}
+
+	if err := r.client.RemoteStore.Put(*object); err != nil {
+		diags.AddError("Unable to Update Remote Object", fmt.Sprintf("Unable to update remote object %q: %s", id, err))
+	}
+
+	return diags
+}
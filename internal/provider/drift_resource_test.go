@@ -0,0 +1,65 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func testDriftResourceConfig(dir string, drift string) string {
+	return fmt.Sprintf(`
+provider "testlagger" {
+	remote_store_path = %[1]q
+}
+
+resource "testlagger_lag" "test" {
+	input = "one"
+}
+%[2]s
+`, dir, drift)
+}
+
+func TestDriftResource(t *testing.T) {
+	dir := t.TempDir()
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testDriftResourceConfig(dir, ""),
+			},
+			// The refresh after applying finds the changed input
+			{
+				Config: testDriftResourceConfig(dir, `
+resource "testlagger_drift" "test" {
+	target_id = testlagger_lag.test.id
+	input = "drifted"
+}
+`),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				RefreshState:       true,
+				Check:              resource.TestCheckResourceAttr("testlagger_lag.test", "input", "drifted"),
+				ExpectNonEmptyPlan: true,
+			},
+			// The refresh after applying finds the object gone
+			{
+				Config: testDriftResourceConfig(dir, `
+resource "testlagger_drift" "test" {
+	target_id = testlagger_lag.test.id
+	action = "delete"
+}
+`),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
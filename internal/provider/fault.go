@@ -0,0 +1,140 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// FaultModel describes the fault injection data model.
+type FaultModel struct {
+	Message     types.String  `tfsdk:"message"`
+	Probability types.Float64 `tfsdk:"probability"`
+	OnCall      types.Int64   `tfsdk:"on_call"`
+	Seed        types.Int64   `tfsdk:"seed"`
+}
+
+// faultObjectType is the type of a single fault.
+var faultObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"message":     types.StringType,
+		"probability": types.Float64Type,
+		"on_call":     types.Int64Type,
+		"seed":        types.Int64Type,
+	},
+}
+
+const (
+	faultDescription            = "Error to inject once the %s delay has elapsed"
+	faultMessageDescription     = "Message of the injected error diagnostic"
+	faultProbabilityDescription = "Probability between 0 and 1 that the error is injected. Defaults to 1 when `on_call` is not set"
+	faultOnCallDescription      = "Inject the error only on the Nth call of this operation made to the provider, counting from 1"
+	faultSeedDescription        = "Seed for the random number generator, making injected errors repeatable between runs"
+)
+
+func resourceFaultAttribute(operation string) resourceschema.SingleNestedAttribute {
+	return resourceschema.SingleNestedAttribute{
+		MarkdownDescription: fmt.Sprintf(faultDescription, operation),
+		Optional:            true,
+		Attributes: map[string]resourceschema.Attribute{
+			"message":     resourceschema.StringAttribute{MarkdownDescription: faultMessageDescription, Required: true},
+			"probability": resourceschema.Float64Attribute{MarkdownDescription: faultProbabilityDescription, Optional: true},
+			"on_call":     resourceschema.Int64Attribute{MarkdownDescription: faultOnCallDescription, Optional: true},
+			"seed":        resourceschema.Int64Attribute{MarkdownDescription: faultSeedDescription, Optional: true},
+		},
+	}
+}
+
+func dataSourceFaultAttribute(operation string) datasourceschema.SingleNestedAttribute {
+	return datasourceschema.SingleNestedAttribute{
+		MarkdownDescription: fmt.Sprintf(faultDescription, operation),
+		Optional:            true,
+		Attributes: map[string]datasourceschema.Attribute{
+			"message":     datasourceschema.StringAttribute{MarkdownDescription: faultMessageDescription, Required: true},
+			"probability": datasourceschema.Float64Attribute{MarkdownDescription: faultProbabilityDescription, Optional: true},
+			"on_call":     datasourceschema.Int64Attribute{MarkdownDescription: faultOnCallDescription, Optional: true},
+			"seed":        datasourceschema.Int64Attribute{MarkdownDescription: faultSeedDescription, Optional: true},
+		},
+	}
+}
+
+func providerFaultAttribute(operation string) providerschema.SingleNestedAttribute {
+	return providerschema.SingleNestedAttribute{
+		MarkdownDescription: fmt.Sprintf(faultDescription, operation),
+		Optional:            true,
+		Attributes: map[string]providerschema.Attribute{
+			"message":     providerschema.StringAttribute{MarkdownDescription: faultMessageDescription, Required: true},
+			"probability": providerschema.Float64Attribute{MarkdownDescription: faultProbabilityDescription, Optional: true},
+			"on_call":     providerschema.Int64Attribute{MarkdownDescription: faultOnCallDescription, Optional: true},
+			"seed":        providerschema.Int64Attribute{MarkdownDescription: faultSeedDescription, Optional: true},
+		},
+	}
+}
+
+// Fires reports whether the fault should be injected into the given call,
+// counting from 1. A fault with neither a probability nor a call number
+// always fires.
+func (m FaultModel) Fires(call int64, key string) bool {
+	if !m.OnCall.IsNull() && m.OnCall.ValueInt64() != call {
+		return false
+	}
+
+	if m.Probability.IsNull() {
+		return true
+	}
+
+	var roll float64
+	defaultSampler.sample(m.Seed.ValueInt64Pointer(), "fault/"+key, func(r *rand.Rand) {
+		roll = r.Float64()
+	})
+
+	return roll < m.Probability.ValueFloat64()
+}
+
+// injectFault adds an error diagnostic when the configured fault fires for
+// the given call. The fault is ignored if it has not been configured.
+func injectFault(ctx context.Context, operation string, fault types.Object, call int64, key string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if fault.IsNull() || fault.IsUnknown() {
+		return diags
+	}
+
+	var model FaultModel
+	diags.Append(fault.As(ctx, &model, basetypes.ObjectAsOptions{})...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	if !model.Probability.IsNull() && (model.Probability.ValueFloat64() < 0 || model.Probability.ValueFloat64() > 1) {
+		diags.AddError(
+			"Invalid Fault",
+			fmt.Sprintf("%s error probability must be between 0 and 1, got %g", operation, model.Probability.ValueFloat64()),
+		)
+
+		return diags
+	}
+
+	if model.Fires(call, key) {
+		diags.AddError(
+			fmt.Sprintf("Injected %s Error", operation),
+			fmt.Sprintf("%s (call %d)", model.Message.ValueString(), call),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,69 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFaultModel_Fires(t *testing.T) {
+	testCases := map[string]struct {
+		fault FaultModel
+		call  int64
+		want  bool
+	}{
+		"always": {
+			fault: FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Null(), OnCall: types.Int64Null(), Seed: types.Int64Null()},
+			call:  1,
+			want:  true,
+		},
+		"on-call-match": {
+			fault: FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Null(), OnCall: types.Int64Value(3), Seed: types.Int64Null()},
+			call:  3,
+			want:  true,
+		},
+		"on-call-mismatch": {
+			fault: FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Null(), OnCall: types.Int64Value(3), Seed: types.Int64Null()},
+			call:  2,
+			want:  false,
+		},
+		"never": {
+			fault: FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Value(0), OnCall: types.Int64Null(), Seed: types.Int64Null()},
+			call:  1,
+			want:  false,
+		},
+		"certain": {
+			fault: FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Value(1), OnCall: types.Int64Null(), Seed: types.Int64Value(1)},
+			call:  1,
+			want:  true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := testCase.fault.Fires(testCase.call, name); got != testCase.want {
+				t.Fatalf("expected %t, got %t", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestFaultModel_FiresProbability(t *testing.T) {
+	fault := FaultModel{Message: types.StringValue("boom"), Probability: types.Float64Value(0.25), OnCall: types.Int64Null(), Seed: types.Int64Value(42)}
+
+	fired := 0
+	for call := int64(1); call <= 10000; call++ {
+		if fault.Fires(call, "probability") {
+			fired++
+		}
+	}
+
+	if fired < 2000 || fired > 3000 {
+		t.Fatalf("expected roughly 2500 of 10000 calls to fail, got %d", fired)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// JobModel describes the asynchronous job data model.
+type JobModel struct {
+	PollInterval       types.Int64   `tfsdk:"poll_interval"`
+	PollCount          types.Int64   `tfsdk:"poll_count"`
+	Duration           types.Int64   `tfsdk:"duration"`
+	FailureProbability types.Float64 `tfsdk:"failure_probability"`
+	Seed               types.Int64   `tfsdk:"seed"`
+}
+
+// jobObjectType is the type of a single job.
+var jobObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"poll_interval":       types.Int64Type,
+		"poll_count":          types.Int64Type,
+		"duration":            types.Int64Type,
+		"failure_probability": types.Float64Type,
+		"seed":                types.Int64Type,
+	},
+}
+
+const (
+	jobDescription                   = "Makes the %s asynchronous: once the %s delay has elapsed, the operation polls a job until it finishes. Set either `poll_count` or `duration`"
+	jobPollIntervalDescription       = "Amount of time in milliseconds between polls of the job"
+	jobPollCountDescription          = "Number of polls after which the job finishes"
+	jobDurationDescription           = "Amount of time in milliseconds after which the job finishes, polled every `poll_interval`"
+	jobFailureProbabilityDescription = "Probability between 0 and 1 that the job fails once it finishes. Defaults to 0"
+	jobSeedDescription               = "Seed for the random number generator, making job failures repeatable between runs"
+)
+
+func resourceJobAttribute(operation string) resourceschema.SingleNestedAttribute {
+	return resourceschema.SingleNestedAttribute{
+		MarkdownDescription: fmt.Sprintf(jobDescription, operation, operation),
+		Optional:            true,
+		Attributes: map[string]resourceschema.Attribute{
+			"poll_interval":       resourceschema.Int64Attribute{MarkdownDescription: jobPollIntervalDescription, Required: true},
+			"poll_count":          resourceschema.Int64Attribute{MarkdownDescription: jobPollCountDescription, Optional: true},
+			"duration":            resourceschema.Int64Attribute{MarkdownDescription: jobDurationDescription, Optional: true},
+			"failure_probability": resourceschema.Float64Attribute{MarkdownDescription: jobFailureProbabilityDescription, Optional: true},
+			"seed":                resourceschema.Int64Attribute{MarkdownDescription: jobSeedDescription, Optional: true},
+		},
+	}
+}
+
+// Polls returns the number of polls after which the job finishes.
+func (m JobModel) Polls() int64 {
+	if !m.PollCount.IsNull() {
+		return m.PollCount.ValueInt64()
+	}
+
+	interval := m.PollInterval.ValueInt64()
+
+	return (m.Duration.ValueInt64() + interval - 1) / interval
+}
+
+// Fails reports whether the finished job fails.
+func (m JobModel) Fails(key string) bool {
+	if m.FailureProbability.IsNull() {
+		return false
+	}
+
+	var roll float64
+	defaultSampler.sample(m.Seed.ValueInt64Pointer(), "job/"+key, func(r *rand.Rand) {
+		roll = r.Float64()
+	})
+
+	return roll < m.FailureProbability.ValueFloat64()
+}
+
+// validate checks the job of the given operation.
+func (m JobModel) validate(operation string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	switch {
+	case m.PollInterval.ValueInt64() < 1:
+		diags.AddError("Invalid Job", fmt.Sprintf("%s job poll_interval must be at least 1, got %d", operation, m.PollInterval.ValueInt64()))
+	case m.PollCount.IsNull() == m.Duration.IsNull():
+		diags.AddError("Invalid Job", fmt.Sprintf("%s job must set exactly one of poll_count and duration", operation))
+	case m.PollCount.ValueInt64() < 0 || m.Duration.ValueInt64() < 0:
+		diags.AddError("Invalid Job", fmt.Sprintf("%s job poll_count and duration must not be negative", operation))
+	case !m.FailureProbability.IsNull() && (m.FailureProbability.ValueFloat64() < 0 || m.FailureProbability.ValueFloat64() > 1):
+		diags.AddError("Invalid Job", fmt.Sprintf("%s job failure_probability must be between 0 and 1, got %g", operation, m.FailureProbability.ValueFloat64()))
+	}
+
+	return diags
+}
+
+// validateJobs checks the jobs of the configuration, so that an invalid job
+// fails validation rather than the apply. Jobs with unknown values are
+// checked when they run.
+func validateJobs(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, job := range []struct{ name, operation string }{
+		{name: "create_job", operation: "Create"},
+		{name: "update_job", operation: "Update"},
+		{name: "delete_job", operation: "Delete"},
+	} {
+		var value types.Object
+
+		diags.Append(config.GetAttribute(ctx, path.Root(job.name), &value)...)
+
+		if diags.HasError() || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+
+		var model JobModel
+		diags.Append(value.As(ctx, &model, basetypes.ObjectAsOptions{})...)
+
+		if diags.HasError() || model.PollInterval.IsUnknown() || model.PollCount.IsUnknown() || model.Duration.IsUnknown() || model.FailureProbability.IsUnknown() {
+			continue
+		}
+
+		for _, err := range model.validate(job.operation) {
+			diags.AddAttributeError(path.Root(job.name), err.Summary(), err.Detail())
+		}
+	}
+
+	return diags
+}
+
+// runJob polls the configured job of the operation at the given lag point
+// until it finishes, adding an error diagnostic if it fails. Each poll sleeps
+// at its own lag point named after the operation, so it shows up in traces
+// and the journal and can be cancelled. The job is skipped if it has not been
+// configured.
+func runJob(ctx context.Context, client *TestLaggerClient, point lagPoint, operation string, job types.Object, key string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if job.IsNull() || job.IsUnknown() {
+		return diags
+	}
+
+	var model JobModel
+	diags.Append(job.As(ctx, &model, basetypes.ObjectAsOptions{})...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	diags.Append(model.validate(operation)...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	pollPoint := point
+	pollPoint.Name += " Poll"
+	pollPoint.Operation += "_poll"
+
+	polls := model.Polls()
+
+	for range polls {
+		// Client polls the job until it finishes
+		diags.Append(sleep(ctx, client, pollPoint, model.PollInterval.ValueInt64())...)
+
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	if model.Fails(key) {
+		diags.AddError(
+			fmt.Sprintf("%s Job Failed", operation),
+			fmt.Sprintf("The job started by %s failed after %d polls", point, polls),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,158 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func testJob(t *testing.T, job JobModel) types.Object {
+	value, diags := types.ObjectValueFrom(context.Background(), jobObjectType.AttrTypes, job)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	return value
+}
+
+func TestJobModel_Polls(t *testing.T) {
+	testCases := map[string]struct {
+		job  JobModel
+		want int64
+	}{
+		"poll-count": {
+			job:  JobModel{PollInterval: types.Int64Value(100), PollCount: types.Int64Value(3), Duration: types.Int64Null()},
+			want: 3,
+		},
+		"duration": {
+			job:  JobModel{PollInterval: types.Int64Value(100), PollCount: types.Int64Null(), Duration: types.Int64Value(250)},
+			want: 3,
+		},
+		"duration-multiple": {
+			job:  JobModel{PollInterval: types.Int64Value(100), PollCount: types.Int64Null(), Duration: types.Int64Value(200)},
+			want: 2,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := testCase.job.Polls(); got != testCase.want {
+				t.Fatalf("expected %d polls, got %d", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	job := JobModel{
+		PollInterval:       types.Int64Value(20),
+		PollCount:          types.Int64Value(3),
+		Duration:           types.Int64Null(),
+		FailureProbability: types.Float64Null(),
+		Seed:               types.Int64Null(),
+	}
+
+	start := time.Now()
+
+	if diags := runJob(context.Background(), nil, testLagPoint, "Create", testJob(t, job), "test"); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("expected three polls of 20ms to take at least 60ms, took %s", elapsed)
+	}
+
+	job.FailureProbability = types.Float64Value(1)
+
+	if diags := runJob(context.Background(), nil, testLagPoint, "Create", testJob(t, job), "test"); !diags.HasError() || diags.Errors()[0].Summary() != "Create Job Failed" {
+		t.Errorf("expected the job to fail, got %v", diags)
+	}
+
+	job.Duration = types.Int64Value(100)
+
+	if diags := runJob(context.Background(), nil, testLagPoint, "Create", testJob(t, job), "test"); !diags.HasError() || diags.Errors()[0].Summary() != "Invalid Job" {
+		t.Errorf("expected a job with both poll_count and duration to be invalid, got %v", diags)
+	}
+}
+
+func TestRunJob_Cancelled(t *testing.T) {
+	job := JobModel{
+		PollInterval:       types.Int64Value(10000),
+		PollCount:          types.Int64Value(3),
+		Duration:           types.Int64Null(),
+		FailureProbability: types.Float64Null(),
+		Seed:               types.Int64Null(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if diags := runJob(ctx, nil, testLagPoint, "Create", testJob(t, job), "test"); !diags.HasError() || diags.Errors()[0].Summary() != "Lag Cancelled" {
+		t.Errorf("expected the job to be cancelled mid-poll, got %v", diags)
+	}
+}
+
+func TestLagResource_ValidateJobs(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+	jobType := stateType.AttributeTypes["update_job"].(tftypes.Object)
+
+	testCases := map[string]struct {
+		pollCount any
+		duration  any
+		want      string
+	}{
+		"valid":           {pollCount: 3},
+		"unknown":         {pollCount: tftypes.UnknownValue, duration: 100},
+		"both":            {pollCount: 3, duration: 100, want: "Invalid Job"},
+		"neither":         {want: "Invalid Job"},
+		"negative-polls":  {pollCount: -1, want: "Invalid Job"},
+		"negative-length": {duration: -1, want: "Invalid Job"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range stateType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+			attributes["update_job"] = tftypes.NewValue(jobType, map[string]tftypes.Value{
+				"poll_interval":       tftypes.NewValue(tftypes.Number, 10),
+				"poll_count":          tftypes.NewValue(tftypes.Number, testCase.pollCount),
+				"duration":            tftypes.NewValue(tftypes.Number, testCase.duration),
+				"failure_probability": tftypes.NewValue(tftypes.Number, nil),
+				"seed":                tftypes.NewValue(tftypes.Number, nil),
+			})
+
+			config, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{TypeName: "testlagger_lag", Config: &config})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if testCase.want == "" && len(resp.Diagnostics) > 0 {
+				t.Errorf("unexpected diagnostic %s: %s", resp.Diagnostics[0].Summary, resp.Diagnostics[0].Detail)
+			}
+
+			if testCase.want != "" && (len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Summary != testCase.want) {
+				t.Errorf("expected %q, got %v", testCase.want, resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Journal event types.
+const (
+	JournalEventStart     = "start"
+	JournalEventFinish    = "finish"
+	JournalEventCancelled = "cancelled"
+)
+
+// JournalEvent is a single line of the journal. Timestamp orders the events
+// of every process, while MonotonicNs is the time since the journal was opened
+// by the process with Pid, as each provider process opens it itself, so it
+// only orders the events of that one process.
+type JournalEvent struct {
+	Event       string    `json:"event"`
+	Operation   string    `json:"operation"`
+	ClientId    string    `json:"client_id"`
+	InstanceId  string    `json:"instance_id"`
+	Input       string    `json:"input,omitempty"`
+	DelayMs     int64     `json:"delay_ms"`
+	ElapsedMs   *int64    `json:"elapsed_ms,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	MonotonicNs int64     `json:"monotonic_ns"`
+	Pid         int       `json:"pid"`
+}
+
+// Journal appends lag point events to a file as JSON lines. Each event is
+// written with a single append while holding both an in-process mutex and,
+// where supported, an exclusive file lock, so that several goroutines and
+// several provider processes can share one journal.
+type Journal struct {
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+}
+
+var (
+	journalsMutex sync.Mutex
+	journals      = map[string]*Journal{}
+)
+
+// OpenJournal opens the journal at path for appending, creating it if
+// necessary. Journals are shared by every client in the process that writes
+// to the same path.
+func OpenJournal(path string) (*Journal, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	journalsMutex.Lock()
+	defer journalsMutex.Unlock()
+
+	if journal, ok := journals[absPath]; ok {
+		return journal, nil
+	}
+
+	file, err := os.OpenFile(absPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{
+		file:  file,
+		start: time.Now(),
+	}
+	journals[absPath] = journal
+
+	return journal, nil
+}
+
+// Record appends the event to the journal, stamping it with the current time
+// once the journal is locked, so that its lines are in time order.
+func (j *Journal) Record(event JournalEvent) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := lockFile(j.file); err != nil {
+		return fmt.Errorf("locking journal: %w", err)
+	}
+
+	defer func() {
+		_ = unlockFile(j.file)
+	}()
+
+	now := time.Now()
+
+	event.Timestamp = now.UTC()
+	event.MonotonicNs = now.Sub(j.start).Nanoseconds()
+	event.Pid = os.Getpid()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.file.Write(append(line, '\n'))
+
+	return err
+}
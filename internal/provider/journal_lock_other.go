@@ -0,0 +1,24 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !(linux || darwin || freebsd || windows)
+
+package provider
+
+import (
+	"os"
+)
+
+// The remaining platforms have no file lock, so only the in-process mutex
+// orders the events of each process, and the lines of several processes
+// sharing a journal may interleave.
+
+func lockFile(_ *os.File) error {
+	return nil
+}
+
+func unlockFile(_ *os.File) error {
+	return nil
+}
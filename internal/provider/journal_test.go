@@ -0,0 +1,111 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func readJournal(t *testing.T, path string) []JournalEvent {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer file.Close()
+
+	var events []JournalEvent
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event JournalEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("journal line %q is not valid JSON: %s", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return events
+}
+
+func TestJournal_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := journal.Record(JournalEvent{
+				Event:     JournalEventStart,
+				Operation: "resource_create",
+				Input:     fmt.Sprintf("input-%d", i),
+				DelayMs:   int64(i),
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	events := readJournal(t, path)
+
+	if len(events) != 50 {
+		t.Fatalf("expected 50 events, got %d", len(events))
+	}
+
+	for i, event := range events {
+		if i > 0 && event.Timestamp.Before(events[i-1].Timestamp) {
+			t.Fatalf("expected events in time order, got %s after %s", event.Timestamp, events[i-1].Timestamp)
+		}
+
+		if event.Pid != os.Getpid() {
+			t.Fatalf("expected pid %d, got %d", os.Getpid(), event.Pid)
+		}
+
+		if event.Timestamp.IsZero() {
+			t.Fatal("expected event to be timestamped")
+		}
+	}
+}
+
+func TestOpenJournal_Shared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	first, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	second, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if first != second {
+		t.Fatal("expected journals with the same path to be shared")
+	}
+}
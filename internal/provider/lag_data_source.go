@@ -7,12 +7,13 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"time"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ datasource.DataSource = &LagDataSource{}
+var _ datasource.DataSourceWithConfigValidators = &LagDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &LagDataSource{}
 
 func NewLagDataSource() datasource.DataSource {
 	return &LagDataSource{}
@@ -23,10 +24,24 @@ type LagDataSource struct {
 	client *TestLaggerClient
 }
 
+// lagDataSourceLagPoints are the lag points that can be given a delay
+// distribution on the lag data source.
+var lagDataSourceLagPoints = []string{"validate", "read"}
+
 type lagDataSourceModel struct {
-	ReadDelay types.Int64  `tfsdk:"read_delay"`
-	Input     types.String `tfsdk:"input"`
-	Output    types.String `tfsdk:"output"`
+	ValidateDelay      types.Int64   `tfsdk:"validate_delay"`
+	ReadDelay          types.Int64   `tfsdk:"read_delay"`
+	DelayDistributions types.Map     `tfsdk:"delay_distributions"`
+	ReadError          types.Object  `tfsdk:"read_error"`
+	ValidateError      types.Object  `tfsdk:"validate_error"`
+	ValidateWarning    types.String  `tfsdk:"validate_warning"`
+	Input              types.String  `tfsdk:"input"`
+	Output             types.String  `tfsdk:"output"`
+	Retries            types.Int64   `tfsdk:"retries"`
+	DeferWhen          types.String  `tfsdk:"defer_when"`
+	Payload            types.Dynamic `tfsdk:"payload"`
+	PayloadSize        types.Int64   `tfsdk:"payload_size"`
+	PayloadOutput      types.Dynamic `tfsdk:"payload_output"`
 }
 
 func (d *LagDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -37,10 +52,21 @@ func (d *LagDataSource) Schema(ctx context.Context, req datasource.SchemaRequest
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Echos the given input after a delay.",
 		Attributes: map[string]schema.Attribute{
+			"validate_delay": schema.Int64Attribute{
+				MarkdownDescription: validateDelayDescription,
+				Optional:            true,
+			},
 			"read_delay": schema.Int64Attribute{
 				MarkdownDescription: "Amount of time in milliseconds to delay before read function returns",
 				Optional:            true,
 			},
+			"delay_distributions": dataSourceDelayDistributionsAttribute(lagDataSourceLagPoints...),
+			"read_error":          dataSourceFaultAttribute("read"),
+			"validate_error":      dataSourceFaultAttribute("validate"),
+			"validate_warning": schema.StringAttribute{
+				MarkdownDescription: validateWarningDescription,
+				Optional:            true,
+			},
 			"input": schema.StringAttribute{
 				MarkdownDescription: "Input string to echo",
 				Required:            true,
@@ -49,6 +75,26 @@ func (d *LagDataSource) Schema(ctx context.Context, req datasource.SchemaRequest
 				MarkdownDescription: "Output string echoed",
 				Computed:            true,
 			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times the read was retried after being throttled by the provider `requests_per_second` in `retry` mode",
+				Computed:            true,
+			},
+			"defer_when": schema.StringAttribute{
+				MarkdownDescription: dataSourceDeferWhenDescription,
+				Optional:            true,
+			},
+			"payload": schema.DynamicAttribute{
+				MarkdownDescription: payloadDescription,
+				Optional:            true,
+			},
+			"payload_size": schema.Int64Attribute{
+				MarkdownDescription: payloadSizeDescription,
+				Optional:            true,
+			},
+			"payload_output": schema.DynamicAttribute{
+				MarkdownDescription: payloadOutputDescription,
+				Computed:            true,
+			},
 		},
 	}
 }
@@ -72,21 +118,36 @@ func (d *LagDataSource) Configure(ctx context.Context, req datasource.ConfigureR
 
 	id := uuid.New().String()
 
-	if client.DatasourceConfigureDelay > 0 {
-		startMessage := fmt.Sprintf("Datasource Lag Configure (%s/%s): Start sleeping for %d seconds...\n", client.Id, id, client.DatasourceConfigureDelay)
-		tflog.Trace(ctx, startMessage)
+	defer client.Concurrency.Start("datasource_configure")()
 
-		time.Sleep(time.Duration(client.DatasourceConfigureDelay) * time.Millisecond)
+	configureDelay := client.DatasourceConfigureDelay.Sample("datasource/configure")
 
-		finishMessage := fmt.Sprintf("Datasource Lag Configure (%s/%s): Finished sleeping for %d seconds...\n", client.Id, id, client.DatasourceConfigureDelay)
-		tflog.Trace(ctx, finishMessage)
+	resp.Diagnostics.Append(sleep(ctx, client, lagPoint{Name: "Datasource Lag Configure", Operation: "datasource_configure", ClientId: client.Id, InstanceId: id}, configureDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	d.client = client
 	d.Id = id
 }
 
+func (d *LagDataSource) ConfigValidators(ctx context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		validationDelay{name: "Datasource Lag Validate", operation: "datasource_validate", key: "datasource/validate", lagPoints: lagDataSourceLagPoints, hasInput: true},
+	}
+}
+
+func (d *LagDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	// Inject configured warnings and errors once the delay has elapsed
+	resp.Diagnostics.Append(injectValidationDiagnostics(ctx, req.Config, "datasource/validate", true)...)
+	resp.Diagnostics.Append(validateDataSourceDeferWhen(ctx, req.Config)...)
+	resp.Diagnostics.Append(validatePayload(ctx, req.Config)...)
+}
+
 func (d *LagDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	defer d.client.Concurrency.Start("datasource_read")()
+
 	var data lagDataSourceModel
 
 	// Read configuration data into the model
@@ -96,34 +157,64 @@ func (d *LagDataSource) Read(ctx context.Context, req datasource.ReadRequest, re
 		return
 	}
 
-	// Read input values
-	var readDelay int64
-	var input string
+	// Defer the read, leaving every attribute unknown
+	if req.ClientCapabilities.DeferralAllowed {
+		if deferred := dataSourceDeferred(deferCondition(d.client, data.DeferWhen, &req.Config)); deferred != nil {
+			resp.State.Raw = tftypes.NewValue(resp.State.Schema.Type().TerraformType(ctx), tftypes.UnknownValue)
+			resp.Deferred = deferred
 
-	if data.ReadDelay.IsNull() || data.ReadDelay.IsUnknown() {
-		readDelay = 0
-	} else {
-		readDelay = data.ReadDelay.ValueInt64()
+			return
+		}
 	}
 
+	// Read input values
+	var input string
+
 	if data.Input.IsNull() || data.Input.IsUnknown() {
 		input = ""
 	} else {
 		input = data.Input.ValueString()
 	}
 
-	if readDelay > 0 {
-		startMessage := fmt.Sprintf("Datasource Lag Read (%s/%s): Start sleeping for %d seconds...\n", d.client.Id, d.Id, readDelay)
-		tflog.Trace(ctx, startMessage)
+	specs, diags := delaySpecs(ctx, data.DelayDistributions, lagDataSourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readDelay := resolveDelay(specs, "read", data.ReadDelay).Sample("datasource/read/" + input)
+	readCall := d.client.CountCall("datasource/read")
+
+	point := lagPoint{Name: "Datasource Lag Read", Operation: "datasource_read", ClientId: d.client.Id, InstanceId: d.Id, Input: input}
+
+	// Client waits its turn at the API
+	retries, done, diags := throttleCall(ctx, d.client, point)
+	resp.Diagnostics.Append(diags...)
 
-		time.Sleep(time.Duration(readDelay) * time.Millisecond)
+	defer done()
 
-		finishMessage := fmt.Sprintf("Datasource Lag Read (%s/%s): Finished sleeping for %d seconds...\n", d.client.Id, d.Id, readDelay)
-		tflog.Trace(ctx, finishMessage)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(sleep(ctx, d.client, point, readDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Inject configured errors once the delay has elapsed
+	resp.Diagnostics.Append(injectFault(ctx, "Read", data.ReadError, readCall, "datasource/read/"+input)...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Set output values
 	data.Output = types.StringValue(input)
+	data.Retries = types.Int64Value(retries)
+	data.PayloadOutput = payloadOutput(data.Payload, data.PayloadSize, input)
 
 	// Save updated data into Terraform state
 	diags = resp.State.Set(ctx, &data)
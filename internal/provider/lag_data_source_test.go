@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"regexp"
 	"testing"
 )
 
@@ -26,6 +27,75 @@ func TestLagDataSource(t *testing.T) {
 	})
 }
 
+func TestLagDataSource_DelayDistributions(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "testlagger_lag" "test" {
+	delay_distributions = {
+		read = {
+			distribution = "uniform"
+			min          = 100
+			max          = 200
+			seed         = 42
+		}
+	}
+	input = "hello"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.testlagger_lag.test", "output", "hello"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagDataSource_ReadError(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "testlagger_lag" "test" {
+	read_error = {
+		message     = "simulated read failure"
+		probability = 1
+	}
+	input = "hello"
+}
+`,
+				ExpectError: regexp.MustCompile(`simulated read failure`),
+			},
+		},
+	})
+}
+
+func TestLagDataSource_ValidateError(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "testlagger_lag" "test" {
+	validate_delay = 100
+	validate_error = {
+		message = "simulated validation failure"
+	}
+	input = "hello"
+}
+`,
+				ExpectError: regexp.MustCompile(`simulated validation failure`),
+			},
+		},
+	})
+}
+
 func testLagDataSourceConfig(readDelay int64, input string) string {
 	return fmt.Sprintf(`
 data "testlagger_lag" "test" {
@@ -0,0 +1,89 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = LagDynamicFunction{}
+)
+
+// NewLagDynamicFunction returns the lag_dynamic function, which is the lag
+// function for values of any type. client returns the configured client, or
+// nil if the provider has not been configured yet.
+func NewLagDynamicFunction(client func() *TestLaggerClient) function.Function {
+	return LagDynamicFunction{
+		client: client,
+	}
+}
+
+type LagDynamicFunction struct {
+	client func() *TestLaggerClient
+}
+
+func (r LagDynamicFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "lag_dynamic"
+}
+
+func (r LagDynamicFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Lag dynamic function",
+		MarkdownDescription: "Echos the given input of any type, such as an object, list or map, after a delay.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				AllowUnknownValues:  false,
+				AllowNullValue:      false,
+				MarkdownDescription: "Amount of time in milliseconds to delay before function returns, or a delay distribution object with the attributes `distribution`, `value`, `min`, `max`, `mean`, `stddev` and `seed`",
+				Name:                "delay",
+			},
+			function.DynamicParameter{
+				AllowUnknownValues:  false,
+				AllowNullValue:      false,
+				Name:                "input",
+				MarkdownDescription: "Value to echo",
+			},
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (r LagDynamicFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	client := r.client()
+
+	if client != nil {
+		defer client.Concurrency.Start("function_lag_dynamic")()
+	}
+
+	var delayValue types.Dynamic
+	var input types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &delayValue, &input))
+
+	if resp.Error != nil {
+		return
+	}
+
+	key := payloadKey(input)
+
+	point := lagPoint{
+		Name:      "Lag Dynamic Function",
+		Operation: "function_lag_dynamic",
+		Input:     key,
+	}
+
+	resp.Error = runLagFunction(ctx, client, point, delayValue, "function/lag_dynamic/"+key)
+
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, input))
+}
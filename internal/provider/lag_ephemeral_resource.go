@@ -0,0 +1,310 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResource = &LagEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &LagEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithRenew = &LagEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithClose = &LagEphemeralResource{}
+
+func NewLagEphemeralResource() ephemeral.EphemeralResource {
+	return &LagEphemeralResource{}
+}
+
+type LagEphemeralResource struct {
+	Id     string
+	client *TestLaggerClient
+}
+
+// lagEphemeralResourceLagPoints are the lag points that can be given a delay
+// distribution on the lag ephemeral resource.
+var lagEphemeralResourceLagPoints = []string{"open", "renew", "close"}
+
+// lagEphemeralResourcePrivateKey is the private data key of the delays
+// needed by Renew and Close, which are not given the configuration.
+const lagEphemeralResourcePrivateKey = "lag"
+
+type lagEphemeralResourceModel struct {
+	OpenDelay          types.Int64  `tfsdk:"open_delay"`
+	RenewDelay         types.Int64  `tfsdk:"renew_delay"`
+	CloseDelay         types.Int64  `tfsdk:"close_delay"`
+	RenewAt            types.Int64  `tfsdk:"renew_at"`
+	DelayDistributions types.Map    `tfsdk:"delay_distributions"`
+	Input              types.String `tfsdk:"input"`
+	Output             types.String `tfsdk:"output"`
+}
+
+// lagEphemeralResourcePrivate is the private data passed from Open to Renew
+// and Close.
+type lagEphemeralResourcePrivate struct {
+	Input      string    `json:"input"`
+	RenewDelay DelaySpec `json:"renew_delay"`
+	CloseDelay DelaySpec `json:"close_delay"`
+	RenewAt    int64     `json:"renew_at,omitempty"`
+}
+
+// renewAt returns when Terraform should next renew, or the zero time when it
+// should not.
+func (p lagEphemeralResourcePrivate) renewAt() time.Time {
+	if p.RenewAt <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(time.Duration(p.RenewAt) * time.Millisecond)
+}
+
+func (r *LagEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_lag"
+}
+
+func (r *LagEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Echos the given input after a delay, without storing it in the plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"open_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before open function returns",
+				Optional:            true,
+			},
+			"renew_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before renew function returns",
+				Optional:            true,
+			},
+			"close_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before close function returns",
+				Optional:            true,
+			},
+			"renew_at": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds after opening, and after each renewal, at which Terraform is asked to renew. Terraform only renews while the ephemeral resource is still in use",
+				Optional:            true,
+			},
+			"delay_distributions": ephemeralDelayDistributionsAttribute(lagEphemeralResourceLagPoints...),
+			"input": schema.StringAttribute{
+				MarkdownDescription: "Input string to echo",
+				Required:            true,
+			},
+			"output": schema.StringAttribute{
+				MarkdownDescription: "Output string echoed",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *LagEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*TestLaggerClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *TestLaggerClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	id := uuid.New().String()
+
+	defer client.Concurrency.Start("ephemeral_configure")()
+
+	configureDelay := client.EphemeralConfigureDelay.Sample("ephemeral/configure")
+
+	// Client does work to initialize
+	resp.Diagnostics.Append(sleep(ctx, client, lagPoint{Name: "Ephemeral Lag Configure", Operation: "ephemeral_configure", ClientId: client.Id, InstanceId: id}, configureDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.client = client
+	r.Id = id
+}
+
+// lagPoint describes a lag point of this ephemeral resource instance.
+func (r *LagEphemeralResource) lagPoint(name string, operation string, input string) lagPoint {
+	return lagPoint{
+		Name:       "Ephemeral Lag " + name,
+		Operation:  operation,
+		ClientId:   r.client.Id,
+		InstanceId: r.Id,
+		Input:      input,
+	}
+}
+
+func (r *LagEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	defer r.client.Concurrency.Start("ephemeral_open")()
+
+	var data lagEphemeralResourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Read input values
+	var input string
+
+	if !data.Input.IsNull() && !data.Input.IsUnknown() {
+		input = data.Input.ValueString()
+	}
+
+	specs, diags := delaySpecs(ctx, data.DelayDistributions, lagEphemeralResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	openDelay := resolveDelay(specs, "open", data.OpenDelay).Sample("ephemeral/open/" + input)
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Open", "ephemeral_open", input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client does work against API
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Open", "ephemeral_open", input), openDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	private := lagEphemeralResourcePrivate{
+		Input:      input,
+		RenewDelay: resolveDelay(specs, "renew", data.RenewDelay),
+		CloseDelay: resolveDelay(specs, "close", data.CloseDelay),
+		RenewAt:    data.RenewAt.ValueInt64(),
+	}
+
+	resp.Diagnostics.Append(setLagEphemeralResourcePrivate(ctx, resp.Private.SetKey, private)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Set output values
+	data.Output = types.StringValue(input)
+
+	resp.RenewAt = private.renewAt()
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+func (r *LagEphemeralResource) Renew(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse) {
+	defer r.client.Concurrency.Start("ephemeral_renew")()
+
+	private, diags := getLagEphemeralResourcePrivate(ctx, req.Private.GetKey)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	renewDelay := private.RenewDelay.Sample("ephemeral/renew/" + private.Input)
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Renew", "ephemeral_renew", private.Input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client does work against API
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Renew", "ephemeral_renew", private.Input), renewDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.RenewAt = private.renewAt()
+}
+
+func (r *LagEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	defer r.client.Concurrency.Start("ephemeral_close")()
+
+	private, diags := getLagEphemeralResourcePrivate(ctx, req.Private.GetKey)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	closeDelay := private.CloseDelay.Sample("ephemeral/close/" + private.Input)
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Close", "ephemeral_close", private.Input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client does work against API
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Close", "ephemeral_close", private.Input), closeDelay)...)
+}
+
+func setLagEphemeralResourcePrivate(ctx context.Context, setKey func(context.Context, string, []byte) diag.Diagnostics, private lagEphemeralResourcePrivate) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	value, err := json.Marshal(private)
+	if err != nil {
+		diags.AddError(
+			"Unable to Save Private Data",
+			fmt.Sprintf("Unable to encode the ephemeral resource delays: %s", err),
+		)
+
+		return diags
+	}
+
+	return setKey(ctx, lagEphemeralResourcePrivateKey, value)
+}
+
+func getLagEphemeralResourcePrivate(ctx context.Context, getKey func(context.Context, string) ([]byte, diag.Diagnostics)) (lagEphemeralResourcePrivate, diag.Diagnostics) {
+	var private lagEphemeralResourcePrivate
+
+	value, diags := getKey(ctx, lagEphemeralResourcePrivateKey)
+
+	if diags.HasError() || value == nil {
+		return private, diags
+	}
+
+	if err := json.Unmarshal(value, &private); err != nil {
+		diags.AddError(
+			"Unable to Read Private Data",
+			fmt.Sprintf("Unable to decode the ephemeral resource delays: %s", err),
+		)
+	}
+
+	return private, diags
+}
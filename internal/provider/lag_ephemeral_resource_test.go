@@ -0,0 +1,62 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+// testEphemeralProtoV6ProviderFactories add the echo provider, which copies
+// its data attribute into the state of its echo resource so that ephemeral
+// values can be checked.
+var testEphemeralProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"testlagger": testProtoV6ProviderFactories["testlagger"],
+	"echo":       echoprovider.NewProviderServer(),
+}
+
+func TestLagEphemeralResource(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testEphemeralProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "testlagger" {
+	ephemeral_configure_delay = 100
+}
+
+ephemeral "testlagger_lag" "test" {
+	open_delay  = 100
+	renew_delay = 100
+	close_delay = 100
+	renew_at    = 100
+	input       = "hello"
+}
+
+provider "echo" {
+	data = ephemeral.testlagger_lag.test.output
+}
+
+resource "echo" "test" {}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data"), knownvalue.StringExact("hello")),
+				},
+			},
+		},
+	})
+}
@@ -7,23 +7,28 @@ package provider
 
 import (
 	"context"
-	"fmt"
 	"github.com/google/uuid"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var (
 	_ function.Function = LagFunction{}
 )
 
-func NewLagFunction() function.Function {
-	return LagFunction{}
+// NewLagFunction returns the lag function. Functions are not configured by
+// the provider, so client returns the configured client, or nil if the
+// provider has not been configured yet.
+func NewLagFunction(client func() *TestLaggerClient) function.Function {
+	return LagFunction{
+		client: client,
+	}
 }
 
-type LagFunction struct{}
+type LagFunction struct {
+	client func() *TestLaggerClient
+}
 
 func (r LagFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
 	resp.Name = "lag"
@@ -34,10 +39,10 @@ func (r LagFunction) Definition(_ context.Context, _ function.DefinitionRequest,
 		Summary:             "Lag function",
 		MarkdownDescription: "Echos the given input after a delay.",
 		Parameters: []function.Parameter{
-			function.Int64Parameter{
+			function.DynamicParameter{
 				AllowUnknownValues:  false,
 				AllowNullValue:      false,
-				MarkdownDescription: "Amount of time in milliseconds to delay before function returns",
+				MarkdownDescription: "Amount of time in milliseconds to delay before function returns, or a delay distribution object with the attributes `distribution`, `value`, `min`, `max`, `mean`, `stddev` and `seed`",
 				Name:                "delay",
 			},
 			function.StringParameter{
@@ -52,27 +57,62 @@ func (r LagFunction) Definition(_ context.Context, _ function.DefinitionRequest,
 }
 
 func (r LagFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
-	var delay int64
+	client := r.client()
+
+	if client != nil {
+		defer client.Concurrency.Start("function_lag")()
+	}
+
+	var delayValue types.Dynamic
 	var input string
 
-	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &delay, &input))
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &delayValue, &input))
 
 	if resp.Error != nil {
 		return
 	}
 
-	if delay > 0 {
-		id := uuid.New().String()
-
-		startMessage := fmt.Sprintf("Lag Function (%s): Start sleeping for %d seconds...\n", id, delay)
-		tflog.Trace(ctx, startMessage)
+	point := lagPoint{
+		Name:      "Lag Function",
+		Operation: "function_lag",
+		Input:     input,
+	}
 
-		time.Sleep(time.Duration(delay) * time.Millisecond)
+	resp.Error = runLagFunction(ctx, client, point, delayValue, "function/lag/"+input)
 
-		finishMessage := fmt.Sprintf("Lag Function (%s): Finished sleeping for %d seconds...\n", id, delay)
-		tflog.Trace(ctx, finishMessage)
+	if resp.Error != nil {
+		return
 	}
+
 	result := input
 
 	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result))
 }
+
+// runLagFunction waits its turn at the API and then sleeps for the delay
+// given as the first argument of a lag function, sampled under key.
+func runLagFunction(ctx context.Context, client *TestLaggerClient, point lagPoint, delayValue types.Dynamic, key string) *function.FuncError {
+	delaySpec, err := delaySpecFromDynamic(delayValue)
+	if err != nil {
+		return function.NewArgumentFuncError(0, err.Error())
+	}
+
+	delay := delaySpec.Sample(key)
+
+	point.InstanceId = uuid.New().String()
+
+	if client != nil {
+		point.ClientId = client.Id
+	}
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, client, point)
+
+	defer done()
+
+	if funcErr := function.FuncErrorFromDiags(ctx, diags); funcErr != nil {
+		return funcErr
+	}
+
+	return function.FuncErrorFromDiags(ctx, sleep(ctx, client, point, delay))
+}
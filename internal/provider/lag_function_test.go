@@ -79,3 +79,43 @@ func TestLagFunction_Unknown(t *testing.T) {
 		},
 	})
 }
+
+func TestLagFunction_DelayDistribution(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			//tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				output "test" {
+					value = provider::testlagger::lag({ distribution = "lognormal", mean = 100, stddev = 50, seed = 7 }, "testvalue")
+				}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "testvalue"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagFunction_InvalidDelayDistribution(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			//tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				output "test" {
+					value = provider::testlagger::lag({ distribution = "pareto" }, "testvalue")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unknown distribution "pareto"`),
+			},
+		},
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ list.ListResource = &LagListResource{}
+var _ list.ListResourceWithConfigure = &LagListResource{}
+
+func NewLagListResource() list.ListResource {
+	return &LagListResource{}
+}
+
+// LagListResource enumerates synthetic remote lag resources, so that they
+// can be found by a query and imported in bulk.
+type LagListResource struct {
+	Id     string
+	client *TestLaggerClient
+}
+
+// lagListResourceLagPoints are the lag points that can be given a delay
+// distribution on the lag list resource.
+var lagListResourceLagPoints = []string{"page"}
+
+const (
+	// defaultLagListNamePattern is the input of each listed object when
+	// name_pattern is not set.
+	defaultLagListNamePattern = "lag-%d"
+
+	// defaultLagListPageSize is the number of objects in each page when
+	// page_size is not set.
+	defaultLagListPageSize = 100
+)
+
+type lagListResourceModel struct {
+	ObjectCount        types.Int64  `tfsdk:"object_count"`
+	NamePattern        types.String `tfsdk:"name_pattern"`
+	Namespace          types.String `tfsdk:"namespace"`
+	PageSize           types.Int64  `tfsdk:"page_size"`
+	PageDelay          types.Int64  `tfsdk:"page_delay"`
+	DelayDistributions types.Map    `tfsdk:"delay_distributions"`
+}
+
+func (r *LagListResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_lag"
+}
+
+func (r *LagListResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Lists synthetic remote lag resources a page at a time, each of which can be imported.",
+		Attributes: map[string]schema.Attribute{
+			"object_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of remote objects to list",
+				Required:            true,
+			},
+			"name_pattern": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Input of each remote object, in which `%%d` is replaced by the number of the object counting from 1. Defaults to `%s`", defaultLagListNamePattern),
+				Optional:            true,
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of every remote object",
+				Optional:            true,
+			},
+			"page_size": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of remote objects in each page. Defaults to %d", defaultLagListPageSize),
+				Optional:            true,
+			},
+			"page_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before each page is returned",
+				Optional:            true,
+			},
+			"delay_distributions": listDelayDistributionsAttribute(lagListResourceLagPoints...),
+		},
+	}
+}
+
+func (r *LagListResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*TestLaggerClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *TestLaggerClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+	r.Id = uuid.New().String()
+}
+
+func (r *LagListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var data lagListResourceModel
+
+	diags := req.Config.Get(ctx, &data)
+
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	pattern := defaultLagListNamePattern
+
+	if !data.NamePattern.IsNull() {
+		pattern = data.NamePattern.ValueString()
+	}
+
+	pageSize := int64(defaultLagListPageSize)
+
+	if !data.PageSize.IsNull() {
+		pageSize = data.PageSize.ValueInt64()
+	}
+
+	if pageSize < 1 {
+		diags.AddAttributeError(
+			path.Root("page_size"),
+			"Invalid Page Size",
+			fmt.Sprintf("page_size must be at least 1, got %d", pageSize),
+		)
+	}
+
+	specs, specDiags := delaySpecs(ctx, data.DelayDistributions, lagListResourceLagPoints...)
+	diags.Append(specDiags...)
+
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	count := data.ObjectCount.ValueInt64()
+
+	if req.Limit > 0 && req.Limit < count {
+		count = req.Limit
+	}
+
+	pageDelay := resolveDelay(specs, "page", data.PageDelay)
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		for first := int64(1); first <= count; first += pageSize {
+			page := lagListPage(first, pageSize)
+
+			// Client fetches the next page from the API
+			if diags := r.listPage(ctx, page, pageDelay.Sample("list/page/"+page)); diags.HasError() {
+				push(list.ListResult{Diagnostics: diags})
+				return
+			}
+
+			for number := first; number < first+pageSize && number <= count; number++ {
+				input := strings.ReplaceAll(pattern, "%d", strconv.FormatInt(number, 10))
+
+				model := newLagResourceModel(input, types.StringValue(input), types.StringValue(input))
+				model.Namespace = data.Namespace
+
+				result := req.NewListResult(ctx)
+				result.DisplayName = input
+
+				// The listed object exists in the API, so that importing it
+				// finds it
+				if r.client != nil && r.client.RemoteStore != nil {
+					if err := seedRemoteObject(r.client.RemoteStore, model); err != nil {
+						result.Diagnostics.AddError("Unable to List Remote Object", fmt.Sprintf("Unable to list remote object %q: %s", input, err))
+					}
+				}
+				result.Diagnostics.Append(result.Identity.Set(ctx, model.identity())...)
+
+				if req.IncludeResource {
+					result.Diagnostics.Append(result.Resource.Set(ctx, model)...)
+				}
+
+				if !push(result) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// lagListPage returns the label of the page of results starting with the
+// given object, counting both from 1.
+func lagListPage(first int64, pageSize int64) string {
+	return strconv.FormatInt((first-1)/pageSize+1, 10)
+}
+
+// listPage sleeps for the delay of a page of results.
+func (r *LagListResource) listPage(ctx context.Context, page string, delay int64) diag.Diagnostics {
+	point := lagPoint{Name: "List Lag Page", Operation: "list_page", InstanceId: r.Id, Input: page}
+
+	if r.client != nil {
+		defer r.client.Concurrency.Start("list_page")()
+
+		point.ClientId = r.client.Id
+	}
+
+	return sleep(ctx, r.client, point, delay)
+}
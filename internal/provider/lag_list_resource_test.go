@@ -0,0 +1,151 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/querycheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestLagListResource(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_14_0),
+		},
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testLagResourceConfig(100, 100, 100, 100, "one"),
+			},
+			{
+				Query: true,
+				Config: `
+provider "testlagger" {}
+
+list "testlagger_lag" "test" {
+	provider = testlagger
+
+	config {
+		object_count = 5
+		name_pattern = "bulk-%d"
+		namespace = "team"
+		page_size = 2
+	}
+}
+`,
+				QueryResultChecks: []querycheck.QueryResultCheck{
+					querycheck.ExpectLength("testlagger_lag.test", 5),
+					querycheck.ExpectIdentity("testlagger_lag.test", map[string]knownvalue.Check{
+						"namespace": knownvalue.StringExact("team"),
+						"input":     knownvalue.StringExact("bulk-5"),
+					}),
+				},
+			},
+		},
+	})
+}
+
+func TestLagListResource_Pages(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	configType := schemaResp.ListResourceSchemas["testlagger_lag"].ValueType()
+
+	config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, map[string]tftypes.Value{
+		"object_count":        tftypes.NewValue(tftypes.Number, 5),
+		"name_pattern":        tftypes.NewValue(tftypes.String, "bulk-%d"),
+		"namespace":           tftypes.NewValue(tftypes.String, nil),
+		"page_size":           tftypes.NewValue(tftypes.Number, 2),
+		"page_delay":          tftypes.NewValue(tftypes.Number, 20),
+		"delay_distributions": tftypes.NewValue(configType.(tftypes.Object).AttributeTypes["delay_distributions"], nil),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listServer, ok := server.(tfprotov6.ListResourceServer)
+	if !ok {
+		t.Fatalf("expected the provider server to serve list resources, got %T", server)
+	}
+
+	start := time.Now()
+
+	stream, err := listServer.ListResource(context.Background(), &tfprotov6.ListResourceRequest{
+		TypeName:        "testlagger_lag",
+		Config:          &config,
+		IncludeResource: true,
+		Limit:           4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var displayNames []string
+
+	for result := range stream.Results {
+		for _, diagnostic := range result.Diagnostics {
+			t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+
+		if result.Identity == nil || result.Resource == nil {
+			t.Fatalf("expected %s to have an identity and a resource", result.DisplayName)
+		}
+
+		displayNames = append(displayNames, result.DisplayName)
+	}
+
+	// Two pages of two objects, as the limit stops the third page
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected two pages to take at least 40ms, took %s", elapsed)
+	}
+
+	want := []string{"bulk-1", "bulk-2", "bulk-3", "bulk-4"}
+
+	if len(displayNames) != len(want) {
+		t.Fatalf("expected %v, got %v", want, displayNames)
+	}
+
+	for i := range want {
+		if displayNames[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, displayNames)
+		}
+	}
+}
+
+func TestLagListPage(t *testing.T) {
+	testCases := map[string]struct {
+		pageSize int64
+		want     []string
+	}{
+		"single": {pageSize: 1, want: []string{"1", "2", "3", "4", "5"}},
+		"pairs":  {pageSize: 2, want: []string{"1", "2", "3"}},
+		"whole":  {pageSize: 5, want: []string{"1"}},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+
+			// Pages are listed as they are in List, for five objects
+			for first := int64(1); first <= 5; first += testCase.pageSize {
+				got = append(got, lagListPage(first, testCase.pageSize))
+			}
+
+			if strings.Join(got, ",") != strings.Join(testCase.want, ",") {
+				t.Errorf("expected pages %v, got %v", testCase.want, got)
+			}
+		})
+	}
+}
@@ -2,18 +2,28 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &LagResource{}
 var _ resource.ResourceWithImportState = &LagResource{}
+var _ resource.ResourceWithModifyPlan = &LagResource{}
+var _ resource.ResourceWithConfigValidators = &LagResource{}
+var _ resource.ResourceWithValidateConfig = &LagResource{}
 
 func NewLagResource() resource.Resource {
 	return &LagResource{}
@@ -24,28 +34,85 @@ type LagResource struct {
 	client *TestLaggerClient
 }
 
+// lagResourceLagPoints are the lag points that can be given a delay
+// distribution on the lag resource.
+var lagResourceLagPoints = []string{"validate", "plan", "create", "read", "update", "delete"}
+
 type LagResourceModel struct {
-	Id          types.String `tfsdk:"id"`
-	CreateDelay types.Int64  `tfsdk:"create_delay"`
-	ReadDelay   types.Int64  `tfsdk:"read_delay"`
-	UpdateDelay types.Int64  `tfsdk:"update_delay"`
-	DeleteDelay types.Int64  `tfsdk:"delete_delay"`
-	Input       types.String `tfsdk:"input"`
-	Output      types.String `tfsdk:"output"`
+	Id                   types.String  `tfsdk:"id"`
+	ValidateDelay        types.Int64   `tfsdk:"validate_delay"`
+	PlanDelay            types.Int64   `tfsdk:"plan_delay"`
+	CreateDelay          types.Int64   `tfsdk:"create_delay"`
+	ReadDelay            types.Int64   `tfsdk:"read_delay"`
+	UpdateDelay          types.Int64   `tfsdk:"update_delay"`
+	DeleteDelay          types.Int64   `tfsdk:"delete_delay"`
+	DelayDistributions   types.Map     `tfsdk:"delay_distributions"`
+	CreateError          types.Object  `tfsdk:"create_error"`
+	ReadError            types.Object  `tfsdk:"read_error"`
+	UpdateError          types.Object  `tfsdk:"update_error"`
+	DeleteError          types.Object  `tfsdk:"delete_error"`
+	ValidateError        types.Object  `tfsdk:"validate_error"`
+	ValidateWarning      types.String  `tfsdk:"validate_warning"`
+	Namespace            types.String  `tfsdk:"namespace"`
+	Input                types.String  `tfsdk:"input"`
+	Output               types.String  `tfsdk:"output"`
+	Retries              types.Int64   `tfsdk:"retries"`
+	ConsistencyDelay     types.Int64   `tfsdk:"consistency_delay"`
+	CreateJob            types.Object  `tfsdk:"create_job"`
+	UpdateJob            types.Object  `tfsdk:"update_job"`
+	DeleteJob            types.Object  `tfsdk:"delete_job"`
+	ReplaceTrigger       types.String  `tfsdk:"replace_trigger"`
+	ReplaceOnInputChange types.Bool    `tfsdk:"replace_on_input_change"`
+	UseStateForUnknown   types.Bool    `tfsdk:"use_state_for_unknown"`
+	ComputedOutputKeys   types.Set     `tfsdk:"computed_output_keys"`
+	ComputedOutputs      types.Map     `tfsdk:"computed_outputs"`
+	DeferKnownUntilApply types.Bool    `tfsdk:"defer_known_until_apply"`
+	DeferWhen            types.String  `tfsdk:"defer_when"`
+	Payload              types.Dynamic `tfsdk:"payload"`
+	PayloadSize          types.Int64   `tfsdk:"payload_size"`
+	PayloadOutput        types.Dynamic `tfsdk:"payload_output"`
+	NestedDepth          types.Int64   `tfsdk:"nested_depth"`
+	NestedBreadth        types.Int64   `tfsdk:"nested_breadth"`
+	NestedSetCardinality types.Int64   `tfsdk:"nested_set_cardinality"`
+	ListNested           types.List    `tfsdk:"list_nested"`
+	SetNested            types.Set     `tfsdk:"set_nested"`
+	MapNested            types.Map     `tfsdk:"map_nested"`
+	ListBlock            types.List    `tfsdk:"list_block"`
+	SetBlock             types.Set     `tfsdk:"set_block"`
 }
 
 func (r *LagResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_lag"
+
+	// The identity includes the input, which can be updated in place
+	resp.ResourceBehavior.MutableIdentity = true
+
+	// Plans still take their delay when the whole provider is deferred
+	resp.ResourceBehavior.ProviderDeferred.EnablePlanModification = true
 }
 
 func (r *LagResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
-	resp.Schema = schema.Schema{
+	resp.Schema = lagResourceSchema()
+}
+
+// lagResourceSchema returns the current schema of the lag resource.
+func lagResourceSchema() schema.Schema {
+	lagSchema := schema.Schema{
 		MarkdownDescription: "Echos the given input after a delay.",
+		Version:             lagResourceSchemaVersion,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				MarkdownDescription: "Unique identifier",
 				Computed:            true,
 			},
+			"validate_delay": schema.Int64Attribute{
+				MarkdownDescription: validateDelayDescription,
+				Optional:            true,
+			},
+			"plan_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before plan modification returns. Defaults to the provider `resource_plan_delay`",
+				Optional:            true,
+			},
 			"create_delay": schema.Int64Attribute{
 				MarkdownDescription: "Amount of time in milliseconds to delay before create function returns",
 				Optional:            true,
@@ -62,16 +129,100 @@ func (r *LagResource) Schema(ctx context.Context, req resource.SchemaRequest, re
 				MarkdownDescription: "Amount of time in milliseconds to delay before delete function returns",
 				Optional:            true,
 			},
+			"delay_distributions": resourceDelayDistributionsAttribute(lagResourceLagPoints...),
+			"create_error":        resourceFaultAttribute("create"),
+			"read_error":          resourceFaultAttribute("read"),
+			"update_error":        resourceFaultAttribute("update"),
+			"delete_error":        resourceFaultAttribute("delete"),
+			"validate_error":      resourceFaultAttribute("validate"),
+			"create_job":          resourceJobAttribute("create"),
+			"update_job":          resourceJobAttribute("update"),
+			"delete_job":          resourceJobAttribute("delete"),
+			"validate_warning": schema.StringAttribute{
+				MarkdownDescription: validateWarningDescription,
+				Optional:            true,
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of the resource identity, alongside the input",
+				Optional:            true,
+			},
 			"input": schema.StringAttribute{
 				MarkdownDescription: "Input string to echo",
 				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIf(
+						requiresReplaceOnInputChange,
+						"Changing the input replaces the resource when replace_on_input_change is true",
+						"Changing the input replaces the resource when `replace_on_input_change` is true",
+					),
+				},
 			},
 			"output": schema.StringAttribute{
 				MarkdownDescription: "Output string echoed",
 				Computed:            true,
 			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times the last create or update was retried after being throttled by the provider `requests_per_second` in `retry` mode",
+				Computed:            true,
+			},
+			"consistency_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds after a create or update during which reads are stale, finding nothing after a create and the previous input and output after an update. The provider `consistency_poll_interval` makes creates and updates wait out the window",
+				Optional:            true,
+			},
+			"replace_trigger": schema.StringAttribute{
+				MarkdownDescription: "Arbitrary value that replaces the resource when changed, rather than updating it in place",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"replace_on_input_change": schema.BoolAttribute{
+				MarkdownDescription: "Replace the resource when the input changes, rather than updating it in place",
+				Optional:            true,
+			},
+			"use_state_for_unknown": schema.BoolAttribute{
+				MarkdownDescription: "Plan `id`, `output` and `computed_outputs` with their prior values when updating the resource without changing its input or computed output keys, and `payload_output` when also leaving `payload` and `payload_size` unchanged, rather than as unknown",
+				Optional:            true,
+			},
+			"computed_output_keys": schema.SetAttribute{
+				MarkdownDescription: "Keys of `computed_outputs`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"computed_outputs": schema.MapAttribute{
+				MarkdownDescription: "The input followed by a slash and the key, for each of `computed_output_keys`. Planned with known keys and values that are unknown until apply",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"defer_known_until_apply": schema.BoolAttribute{
+				MarkdownDescription: "Plan `computed_outputs` as wholly unknown until apply, keys included, so that it cannot be used in `for_each`",
+				Optional:            true,
+			},
+			"defer_when": schema.StringAttribute{
+				MarkdownDescription: deferWhenDescription,
+				Optional:            true,
+			},
+			"payload": schema.DynamicAttribute{
+				MarkdownDescription: payloadDescription,
+				Optional:            true,
+			},
+			"payload_size": schema.Int64Attribute{
+				MarkdownDescription: payloadSizeDescription,
+				Optional:            true,
+			},
+			"payload_output": schema.DynamicAttribute{
+				MarkdownDescription: payloadOutputDescription,
+				Computed:            true,
+			},
 		},
+		Blocks: lagResourceNestedBlocks(),
 	}
+
+	for name, attribute := range lagResourceNestedAttributes() {
+		lagSchema.Attributes[name] = attribute
+	}
+
+	return lagSchema
 }
 
 func (r *LagResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -92,22 +243,122 @@ func (r *LagResource) Configure(ctx context.Context, req resource.ConfigureReque
 
 	id := uuid.New().String()
 
-	// Client does work to initialize
-	if client.ResourceConfigureDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Configure (%s/%s): Start sleeping for %d seconds...\n", client.Id, id, client.ResourceConfigureDelay)
-		tflog.Trace(ctx, startMessage)
+	defer client.Concurrency.Start("resource_configure")()
+
+	configureDelay := client.ResourceConfigureDelay.Sample("resource/configure")
 
-		time.Sleep(time.Duration(client.ResourceConfigureDelay) * time.Millisecond)
+	// Client does work to initialize
+	resp.Diagnostics.Append(sleep(ctx, client, lagPoint{Name: "Resource Lag Configure", Operation: "resource_configure", ClientId: client.Id, InstanceId: id}, configureDelay)...)
 
-		finishMessage := fmt.Sprintf("Resource Lag Configure (%s/%s): Finished sleeping for %d seconds...\n", client.Id, id, client.ResourceConfigureDelay)
-		tflog.Trace(ctx, finishMessage)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	r.client = client
 	r.Id = id
 }
 
+// lagPoint describes a lag point of this resource instance.
+func (r *LagResource) lagPoint(name string, operation string, input string) lagPoint {
+	return lagPoint{
+		Name:       "Resource Lag " + name,
+		Operation:  operation,
+		ClientId:   r.client.Id,
+		InstanceId: r.Id,
+		Input:      input,
+	}
+}
+
+// requiresReplaceOnInputChange replaces the resource when its input changes,
+// if replace_on_input_change is true.
+func requiresReplaceOnInputChange(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	var replace types.Bool
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("replace_on_input_change"), &replace)...)
+
+	resp.RequiresReplace = replace.ValueBool()
+}
+
+func (r *LagResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		validationDelay{name: "Resource Lag Validate", operation: "resource_validate", key: "resource/validate", lagPoints: lagResourceLagPoints, hasInput: true},
+	}
+}
+
+func (r *LagResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	// Inject configured warnings and errors once the delay has elapsed
+	resp.Diagnostics.Append(injectValidationDiagnostics(ctx, req.Config, "resource/validate", true)...)
+	resp.Diagnostics.Append(validateDeferWhen(ctx, req.Config)...)
+	resp.Diagnostics.Append(validatePayload(ctx, req.Config)...)
+	resp.Diagnostics.Append(validateNested(ctx, req.Config)...)
+	resp.Diagnostics.Append(validateJobs(ctx, req.Config)...)
+}
+
+func (r *LagResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Plan which computed values are known before apply
+	planLagResourceUnknowns(ctx, req, resp)
+	planLagResourceNested(ctx, req, resp)
+
+	// Prevent panic if the provider has not been configured.
+	if r.client == nil || resp.Diagnostics.HasError() {
+		return
+	}
+
+	defer r.client.Concurrency.Start("resource_plan")()
+
+	var data LagResourceModel
+
+	// Read the planned state, or the prior state when planning to destroy
+	if req.Plan.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	} else {
+		resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Read input values
+	var input string
+
+	if !data.Input.IsNull() && !data.Input.IsUnknown() {
+		input = data.Input.ValueString()
+	}
+
+	specs, diags := delaySpecs(ctx, data.DelayDistributions, lagResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planDelay := resolveDelayOrDefault(specs, "plan", data.PlanDelay, r.client.ResourcePlanDelay).Sample("resource/plan/" + input)
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Plan", "resource_plan", input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client diffs against API
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Plan", "resource_plan", input), planDelay)...)
+
+	if resp.Diagnostics.HasError() || req.Plan.Raw.IsNull() || !req.ClientCapabilities.DeferralAllowed {
+		return
+	}
+
+	// Defer the change to a later plan once its cost has been paid
+	resp.Deferred = resourceDeferred(deferCondition(r.client, data.DeferWhen, &req.Config))
+}
+
 func (r *LagResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer r.client.Concurrency.Start("resource_create")()
+
 	var plannedState LagResourceModel
 
 	// Read Terraform plan plannedState into the model
@@ -118,42 +369,103 @@ func (r *LagResource) Create(ctx context.Context, req resource.CreateRequest, re
 	}
 
 	// Read input values
-	var createDelay int64
 	var input string
 
-	if plannedState.CreateDelay.IsNull() || plannedState.CreateDelay.IsUnknown() {
-		createDelay = 0
-	} else {
-		createDelay = plannedState.CreateDelay.ValueInt64()
-	}
-
 	if plannedState.Input.IsNull() || plannedState.Input.IsUnknown() {
 		input = ""
 	} else {
 		input = plannedState.Input.ValueString()
 	}
 
+	specs, diags := delaySpecs(ctx, plannedState.DelayDistributions, lagResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createDelay := resolveDelay(specs, "create", plannedState.CreateDelay).Sample("resource/create/" + input)
+	createCall := r.client.CountCall("resource/create")
+
+	// Client waits its turn at the API
+	retries, done, diags := throttleCall(ctx, r.client, r.lagPoint("Create", "resource_create", input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Client does work against API
-	if createDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Create (%s/%s): Start sleeping for %d seconds...\n", r.client.Id, r.Id, createDelay)
-		tflog.Trace(ctx, startMessage)
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Create", "resource_create", input), createDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Inject configured errors once the delay has elapsed
+	resp.Diagnostics.Append(injectFault(ctx, "Create", plannedState.CreateError, createCall, "resource/create/"+input)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		time.Sleep(time.Duration(createDelay) * time.Millisecond)
+	// Client waits for the job started by the API
+	resp.Diagnostics.Append(runJob(ctx, r.client, r.lagPoint("Create", "resource_create", input), "Create", plannedState.CreateJob, "resource/create/"+input)...)
 
-		finishMessage := fmt.Sprintf("Resource Lag Create (%s/%s): Finished sleeping for %d seconds...\n", r.client.Id, r.Id, createDelay)
-		tflog.Trace(ctx, finishMessage)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Set state
 	plannedState.Output = types.StringValue(input)
 	plannedState.Id = types.StringValue(input)
+	plannedState.Retries = types.Int64Value(retries)
+	plannedState.PayloadOutput = payloadOutput(plannedState.Payload, plannedState.PayloadSize, input)
+	plannedState.setNested()
+
+	plannedState.ComputedOutputs, diags = plannedState.computedOutputs(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Create the remote object
+	if r.client.RemoteStore != nil {
+		generation := uuid.New().String()
+
+		if err := r.client.RemoteStore.Put(plannedState.remoteObject(generation)); err != nil {
+			resp.Diagnostics.AddError("Unable to Create Remote Object", fmt.Sprintf("Unable to create remote object %q: %s", input, err))
+			return
+		}
+
+		// The generation is a UUID, which always encodes
+		value, _ := json.Marshal(generation)
+
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, lagResourcePrivateGenerationKey, value)...)
+	}
+
+	consistency := newLagResourceConsistency(plannedState.ConsistencyDelay, nil)
 
 	// Save plannedState into Terraform state
-	diags := resp.State.Set(ctx, &plannedState)
+	diags = resp.State.Set(ctx, &plannedState)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, plannedState.identity())...)
+	resp.Diagnostics.Append(setLagResourceConsistency(ctx, resp.Private.SetKey, consistency)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client waits until the API returns what it created
+	resp.Diagnostics.Append(r.waitForConsistency(ctx, consistency, input)...)
 }
 
 func (r *LagResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer r.client.Concurrency.Start("resource_read")()
+
 	var state LagResourceModel
 
 	// Read state state into the model
@@ -163,32 +475,119 @@ func (r *LagResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
+	// The identity of the prior state, before the remote object answers
+	priorIdentity := state.identity()
+
+	// Defer the refresh, keeping the prior state
+	if req.ClientCapabilities.DeferralAllowed {
+		if deferred := resourceDeferred(deferCondition(r.client, state.DeferWhen, nil)); deferred != nil {
+			resp.Diagnostics.Append(resp.Identity.Set(ctx, state.identity())...)
+			resp.Deferred = deferred
+
+			return
+		}
+	}
+
 	// Read input values
-	var readDelay int64
+	specs, diags := delaySpecs(ctx, state.DelayDistributions, lagResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
 
-	if state.ReadDelay.IsNull() || state.ReadDelay.IsUnknown() {
-		readDelay = 0
-	} else {
-		readDelay = state.ReadDelay.ValueInt64()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readDelay := resolveDelay(specs, "read", state.ReadDelay).Sample("resource/read/" + state.Input.ValueString())
+	readCall := r.client.CountCall("resource/read")
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Read", "resource_read", state.Input.ValueString()))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Client does work against API
-	if readDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Read (%s/%s): Start sleeping for %d seconds...\n", r.client.Id, r.Id, readDelay)
-		tflog.Trace(ctx, startMessage)
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Read", "resource_read", state.Input.ValueString()), readDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Inject configured errors once the delay has elapsed
+	resp.Diagnostics.Append(injectFault(ctx, "Read", state.ReadError, readCall, "resource/read/"+state.Input.ValueString())...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Answer from the remote object, which may have drifted or been deleted
+	if r.client.RemoteStore != nil {
+		object, err := r.client.RemoteStore.Get(state.Id.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to Read Remote Object", fmt.Sprintf("Unable to read remote object %q: %s", state.Id.ValueString(), err))
+			return
+		}
+
+		if object == nil {
+			removeLagResource(ctx, resp, priorIdentity)
+			return
+		}
+
+		state.Namespace = types.StringPointerValue(object.Namespace)
+		state.Input = types.StringValue(object.Input)
+		state.Output = types.StringValue(object.Output)
+	}
+
+	// Answer with stale data until the last create or update is consistent
+	consistency, diags := getLagResourceConsistency(ctx, req.Private.GetKey)
+	resp.Diagnostics.Append(diags...)
 
-		time.Sleep(time.Duration(readDelay) * time.Millisecond)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		finishMessage := fmt.Sprintf("Resource Lag Read (%s/%s): Finished sleeping for %d seconds...\n", r.client.Id, r.Id, readDelay)
-		tflog.Trace(ctx, finishMessage)
+	switch {
+	case consistency.stale(time.Now()) && consistency.Previous == nil:
+		removeLagResource(ctx, resp, priorIdentity)
+		return
+	case consistency.stale(time.Now()):
+		state.Id = types.StringValue(consistency.Previous.Id)
+		state.Input = types.StringValue(consistency.Previous.Input)
+		state.Output = types.StringValue(consistency.Previous.Output)
+	case consistency != nil:
+		resp.Diagnostics.Append(setLagResourceConsistency(ctx, resp.Private.SetKey, nil)...)
+	}
+
+	// The computed outputs follow the input, which may have drifted
+	state.setNested()
+	state.ComputedOutputs, diags = state.computedOutputs(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Save updated state into Terraform state
-	diags := resp.State.Set(ctx, &state)
+	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, state.identity())...)
+}
+
+// removeLagResource removes a resource found missing when reading it from
+// state. The framework requires an identity after every read, even one that
+// removes the resource, so it is set from the prior state for state written
+// before identity existed, upgraded or imported, whose identity is null.
+func removeLagResource(ctx context.Context, resp *resource.ReadResponse, identity lagResourceIdentityModel) {
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, identity)...)
+	resp.State.RemoveResource(ctx)
 }
 
 func (r *LagResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer r.client.Concurrency.Start("resource_update")()
+
 	// Read Terraform plan plannedState into the model
 	var state LagResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -205,47 +604,144 @@ func (r *LagResource) Update(ctx context.Context, req resource.UpdateRequest, re
 	}
 
 	// Read input values
-	var updateDelay int64
 	var input string
 
-	if plannedState.UpdateDelay.IsNull() || plannedState.UpdateDelay.IsUnknown() {
-		updateDelay = 0
-	} else {
-		updateDelay = plannedState.UpdateDelay.ValueInt64()
-	}
-
 	if plannedState.Input.IsNull() || plannedState.Input.IsUnknown() {
 		input = ""
 	} else {
 		input = plannedState.Input.ValueString()
 	}
 
+	specs, diags := delaySpecs(ctx, plannedState.DelayDistributions, lagResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateDelay := resolveDelay(specs, "update", plannedState.UpdateDelay).Sample("resource/update/" + input)
+	updateCall := r.client.CountCall("resource/update")
+
+	// Client waits its turn at the API
+	retries, done, diags := throttleCall(ctx, r.client, r.lagPoint("Update", "resource_update", input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Client does work against API
-	if updateDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Update (%s/%s): Start sleeping for %d seconds...\n", r.client.Id, r.Id, updateDelay)
-		tflog.Trace(ctx, startMessage)
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Update", "resource_update", input), updateDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Inject configured errors once the delay has elapsed
+	resp.Diagnostics.Append(injectFault(ctx, "Update", plannedState.UpdateError, updateCall, "resource/update/"+input)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		time.Sleep(time.Duration(updateDelay) * time.Millisecond)
+	// Client waits for the job started by the API
+	resp.Diagnostics.Append(runJob(ctx, r.client, r.lagPoint("Update", "resource_update", input), "Update", plannedState.UpdateJob, "resource/update/"+input)...)
 
-		finishMessage := fmt.Sprintf("Resource Lag Update (%s/%s): Finished sleeping for %d seconds...\n", r.client.Id, r.Id, updateDelay)
-		tflog.Trace(ctx, finishMessage)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	prior := state
+	priorId := state.Id.ValueString()
+
 	// Set state
 	state.Id = types.StringValue(input)
 	state.Input = plannedState.Input
 	state.Output = types.StringValue(input)
+	state.Retries = types.Int64Value(retries)
+	state.ValidateDelay = plannedState.ValidateDelay
+	state.PlanDelay = plannedState.PlanDelay
 	state.CreateDelay = plannedState.CreateDelay
 	state.ReadDelay = plannedState.ReadDelay
 	state.UpdateDelay = plannedState.UpdateDelay
 	state.DeleteDelay = plannedState.DeleteDelay
+	state.DelayDistributions = plannedState.DelayDistributions
+	state.CreateError = plannedState.CreateError
+	state.ReadError = plannedState.ReadError
+	state.UpdateError = plannedState.UpdateError
+	state.DeleteError = plannedState.DeleteError
+	state.ValidateError = plannedState.ValidateError
+	state.ValidateWarning = plannedState.ValidateWarning
+	state.Namespace = plannedState.Namespace
+	state.ConsistencyDelay = plannedState.ConsistencyDelay
+	state.CreateJob = plannedState.CreateJob
+	state.UpdateJob = plannedState.UpdateJob
+	state.DeleteJob = plannedState.DeleteJob
+	state.ReplaceTrigger = plannedState.ReplaceTrigger
+	state.ReplaceOnInputChange = plannedState.ReplaceOnInputChange
+	state.UseStateForUnknown = plannedState.UseStateForUnknown
+	state.ComputedOutputKeys = plannedState.ComputedOutputKeys
+	state.DeferKnownUntilApply = plannedState.DeferKnownUntilApply
+	state.DeferWhen = plannedState.DeferWhen
+	state.Payload = plannedState.Payload
+	state.PayloadSize = plannedState.PayloadSize
+	state.PayloadOutput = payloadOutput(plannedState.Payload, plannedState.PayloadSize, input)
+	state.NestedDepth = plannedState.NestedDepth
+	state.NestedBreadth = plannedState.NestedBreadth
+	state.NestedSetCardinality = plannedState.NestedSetCardinality
+	state.ListBlock = plannedState.ListBlock
+	state.SetBlock = plannedState.SetBlock
+	state.setNested()
+
+	state.ComputedOutputs, diags = state.computedOutputs(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Replace the remote object, whose id follows the input
+	if r.client.RemoteStore != nil {
+		generation, diags := getLagResourceGeneration(ctx, req.Private.GetKey)
+		resp.Diagnostics.Append(diags...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		err := r.client.RemoteStore.Put(state.remoteObject(generation))
+
+		if err == nil && priorId != input {
+			err = r.client.RemoteStore.Delete(priorId)
+		}
+
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to Update Remote Object", fmt.Sprintf("Unable to update remote object %q: %s", priorId, err))
+			return
+		}
+	}
+
+	consistency := newLagResourceConsistency(state.ConsistencyDelay, &prior)
 
 	// Save updated plannedState into Terraform state
-	diags := resp.State.Set(ctx, &state)
+	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, state.identity())...)
+	resp.Diagnostics.Append(setLagResourceConsistency(ctx, resp.Private.SetKey, consistency)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client waits until the API returns what it updated
+	resp.Diagnostics.Append(r.waitForConsistency(ctx, consistency, input)...)
 }
 
 func (r *LagResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	defer r.client.Concurrency.Start("resource_delete")()
+
 	var data LagResourceModel
 
 	// Read Terraform prior state data into the model
@@ -256,44 +752,263 @@ func (r *LagResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 	}
 
 	// Read input values
-	var deleteDelay int64
+	specs, diags := delaySpecs(ctx, data.DelayDistributions, lagResourceLagPoints...)
+	resp.Diagnostics.Append(diags...)
 
-	if data.DeleteDelay.IsNull() || data.DeleteDelay.IsUnknown() {
-		deleteDelay = 0
-	} else {
-		deleteDelay = data.DeleteDelay.ValueInt64()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteDelay := resolveDelay(specs, "delete", data.DeleteDelay).Sample("resource/delete/" + data.Input.ValueString())
+	deleteCall := r.client.CountCall("resource/delete")
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Delete", "resource_delete", data.Input.ValueString()))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Client does work against API
-	if deleteDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Delete (%s/%s): Start sleeping for %d seconds...\n", r.client.Id, r.Id, deleteDelay)
-		tflog.Trace(ctx, startMessage)
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Delete", "resource_delete", data.Input.ValueString()), deleteDelay)...)
 
-		time.Sleep(time.Duration(deleteDelay) * time.Millisecond)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		finishMessage := fmt.Sprintf("Resource Lag Delete (%s/%s): Finished sleeping for %d seconds...\n", r.client.Id, r.Id, deleteDelay)
-		tflog.Trace(ctx, finishMessage)
+	// Inject configured errors once the delay has elapsed
+	resp.Diagnostics.Append(injectFault(ctx, "Delete", data.DeleteError, deleteCall, "resource/delete/"+data.Input.ValueString())...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Client waits for the job started by the API
+	resp.Diagnostics.Append(runJob(ctx, r.client, r.lagPoint("Delete", "resource_delete", data.Input.ValueString()), "Delete", data.DeleteJob, "resource/delete/"+data.Input.ValueString())...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Delete the remote object, unless it now belongs to a replacement created
+	// before this resource was destroyed
+	if r.client.RemoteStore != nil {
+		generation, diags := getLagResourceGeneration(ctx, req.Private.GetKey)
+		resp.Diagnostics.Append(diags...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		object, err := r.client.RemoteStore.Get(data.Id.ValueString())
+
+		if err == nil && object != nil && (generation == "" || object.Generation == "" || object.Generation == generation) {
+			err = r.client.RemoteStore.Delete(data.Id.ValueString())
+		}
+
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to Delete Remote Object", fmt.Sprintf("Unable to delete remote object %q: %s", data.Id.ValueString(), err))
+		}
 	}
 }
 
 func (r *LagResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	defer r.client.Concurrency.Start("resource_import_state")()
+
+	var model LagResourceModel
+
+	byIdentity := req.Identity != nil && !req.Identity.Raw.IsNull()
+
+	if byIdentity {
+		var identity lagResourceIdentityModel
+
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		model = newLagResourceModel(identity.Input.ValueString(), identity.Input, identity.Input)
+		model.Namespace = identity.Namespace
+	} else {
+		var err error
+
+		model, err = parseLagResourceImportID(req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Invalid Import ID",
+				fmt.Sprintf("Unable to import %q: %s. Expected an ID of the form input;create=100;read=50", req.ID, err),
+			)
+
+			return
+		}
+	}
+
+	// Defer the import when the ID asks for it, or when the provider
+	// configuration is unknown
+	if req.ClientCapabilities.DeferralAllowed {
+		deferWhen := model.DeferWhen
+
+		if deferWhen.IsNull() {
+			deferWhen = types.StringValue(DeferWhenProviderConfigUnknown)
+		}
+
+		// The imported object is unknown until the deferral resolves
+		if deferred := resourceDeferred(deferCondition(r.client, deferWhen, nil)); deferred != nil {
+			resp.State.Raw = tftypes.NewValue(resp.State.Schema.Type().TerraformType(ctx), tftypes.UnknownValue)
+			resp.Deferred = deferred
+
+			return
+		}
+	}
+
+	input := model.Input.ValueString()
+	importStateDelay := r.client.ResourceImportStateDelay.Sample("resource/import_state/" + input)
+
+	// Client waits its turn at the API
+	_, done, diags := throttleCall(ctx, r.client, r.lagPoint("Import State", "resource_import_state", input))
+	resp.Diagnostics.Append(diags...)
+
+	defer done()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	// Client does work against API
-	if r.client.ResourceImportStateDelay > 0 {
-		startMessage := fmt.Sprintf("Resource Lag Import State (%s/%s): Start sleeping for %d seconds...\n", r.client.Id, r.Id, r.client.ResourceImportStateDelay)
-		tflog.Trace(ctx, startMessage)
+	resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Import State", "resource_import_state", input), importStateDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		time.Sleep(time.Duration(r.client.ResourceConfigureDelay) * time.Millisecond)
+	if byIdentity {
+		identityDelay := r.client.ResourceIdentityDelay.Sample("resource/identity/" + input)
+
+		// Client looks up the resource by its identity
+		resp.Diagnostics.Append(sleep(ctx, r.client, r.lagPoint("Identity", "resource_identity", input), identityDelay)...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// The imported object exists in the API, so that the read following the
+	// import finds it
+	if r.client.RemoteStore != nil {
+		if err := seedRemoteObject(r.client.RemoteStore, model); err != nil {
+			resp.Diagnostics.AddError("Unable to Import Remote Object", fmt.Sprintf("Unable to import remote object %q: %s", input, err))
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+	resp.Diagnostics.Append(resp.Identity.Set(ctx, model.identity())...)
+}
+
+// remoteObject returns the copy of the resource kept by the remote store,
+// with the given generation.
+func (m LagResourceModel) remoteObject(generation string) RemoteObject {
+	return RemoteObject{
+		Id:         m.Id.ValueString(),
+		Namespace:  m.Namespace.ValueStringPointer(),
+		Input:      m.Input.ValueString(),
+		Output:     m.Output.ValueString(),
+		Generation: generation,
+	}
+}
+
+// seedRemoteObject puts the remote object of an imported or listed resource
+// in the store, without a generation, unless the store already holds one,
+// which is kept so that its drift is imported too.
+func seedRemoteObject(store ObjectStore, model LagResourceModel) error {
+	object, err := store.Get(model.Id.ValueString())
+	if err != nil || object != nil {
+		return err
+	}
+
+	return store.Put(model.remoteObject(""))
+}
+
+// lagResourcePrivateGenerationKey is the private state key of the generation
+// of the remote object created by a lag resource.
+const lagResourcePrivateGenerationKey = "generation"
+
+// getLagResourceGeneration returns the generation of the remote object
+// created by a lag resource, or "" for a resource that was imported or
+// created before generations were recorded.
+func getLagResourceGeneration(ctx context.Context, getKey func(context.Context, string) ([]byte, diag.Diagnostics)) (string, diag.Diagnostics) {
+	var generation string
+
+	value, diags := getKey(ctx, lagResourcePrivateGenerationKey)
+
+	if diags.HasError() || value == nil {
+		return generation, diags
+	}
+
+	if err := json.Unmarshal(value, &generation); err != nil {
+		diags.AddError(
+			"Unable to Read Private Data",
+			fmt.Sprintf("Unable to decode the remote object generation: %s", err),
+		)
+	}
+
+	return generation, diags
+}
 
-		finishedMessage := fmt.Sprintf("Resource Lag Import State (%s/%s): Finished sleeping for %d seconds...\n", r.client.Id, r.Id, r.client.ResourceImportStateDelay)
-		tflog.Trace(ctx, finishedMessage)
+// parseLagResourceImportID returns the state encoded in an import ID of the
+// form input;create=100;read=50. The input may be followed by a namespace,
+// a defer_when and by the delay in milliseconds of any lag point of the
+// resource.
+func parseLagResourceImportID(id string) (LagResourceModel, error) {
+	parts := strings.Split(id, ";")
+
+	model := newLagResourceModel(parts[0], types.StringValue(parts[0]), types.StringValue(parts[0]))
+
+	delays := map[string]*types.Int64{
+		"validate": &model.ValidateDelay,
+		"plan":     &model.PlanDelay,
+		"create":   &model.CreateDelay,
+		"read":     &model.ReadDelay,
+		"update":   &model.UpdateDelay,
+		"delete":   &model.DeleteDelay,
 	}
 
-	model := &LagResourceModel{
-		Id:     types.StringValue(req.ID),
-		Input:  types.StringValue(req.ID),
-		Output: types.StringValue(req.ID),
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return model, fmt.Errorf("expected key=value, got %q", part)
+		}
+
+		if key == "namespace" {
+			model.Namespace = types.StringValue(value)
+			continue
+		}
+
+		if key == "defer_when" {
+			if value != DeferWhenProviderConfigUnknown && value != DeferWhenAlways {
+				return model, fmt.Errorf("defer_when must be %s or %s when importing, got %q", DeferWhenProviderConfigUnknown, DeferWhenAlways, value)
+			}
+
+			model.DeferWhen = types.StringValue(value)
+			continue
+		}
+
+		delay, ok := delays[key]
+		if !ok {
+			return model, fmt.Errorf("unknown key %q, expected namespace, defer_when or one of %s", key, strings.Join(lagResourceLagPoints, ", "))
+		}
+
+		milliseconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || milliseconds < 0 {
+			return model, fmt.Errorf("%s must be a non-negative number of milliseconds, got %q", key, value)
+		}
+
+		*delay = types.Int64Value(milliseconds)
 	}
 
-	resp.State.Set(ctx, model)
+	return model, nil
 }
@@ -0,0 +1,152 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// lagResourcePrivateConsistencyKey is the private state key of the window
+// during which reads of a lag resource are stale.
+const lagResourcePrivateConsistencyKey = "consistency"
+
+// lagResourceConsistency is the window after a create or update during which
+// reads of a lag resource are stale. It is kept in private state, so that
+// refreshes in later runs see it too.
+type lagResourceConsistency struct {
+	ConsistentAt time.Time `json:"consistent_at"`
+
+	// Previous is what reads return until the resource is consistent, or nil
+	// when they find nothing, after a create.
+	Previous *lagResourceStaleValues `json:"previous,omitempty"`
+}
+
+// lagResourceStaleValues are the values of a lag resource before an update.
+type lagResourceStaleValues struct {
+	Id     string `json:"id"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// newLagResourceConsistency returns the window starting now of a resource
+// with the given consistency_delay, or nil when it has none. The prior state
+// is nil after a create.
+func newLagResourceConsistency(delay types.Int64, prior *LagResourceModel) *lagResourceConsistency {
+	if delay.ValueInt64() <= 0 {
+		return nil
+	}
+
+	consistency := &lagResourceConsistency{
+		ConsistentAt: time.Now().Add(time.Duration(delay.ValueInt64()) * time.Millisecond),
+	}
+
+	if prior != nil {
+		consistency.Previous = &lagResourceStaleValues{
+			Id:     prior.Id.ValueString(),
+			Input:  prior.Input.ValueString(),
+			Output: prior.Output.ValueString(),
+		}
+	}
+
+	return consistency
+}
+
+// stale returns whether reads at the given time are still stale.
+func (c *lagResourceConsistency) stale(now time.Time) bool {
+	return c != nil && now.Before(c.ConsistentAt)
+}
+
+// waitForConsistency polls a created or updated resource until reads are
+// consistent, when the provider consistency_poll_interval is set. Each poll
+// sleeps for the interval at its own lag point, and the resource is given up
+// on once the next poll would end after the provider consistency_timeout.
+func (r *LagResource) waitForConsistency(ctx context.Context, consistency *lagResourceConsistency, input string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	interval := r.client.ConsistencyPollInterval
+
+	if interval <= 0 {
+		return diags
+	}
+
+	start := time.Now()
+
+	for polls := int64(0); consistency.stale(time.Now()); polls++ {
+		elapsed := time.Since(start)
+
+		if r.client.ConsistencyTimeout > 0 && elapsed+time.Duration(interval)*time.Millisecond > time.Duration(r.client.ConsistencyTimeout)*time.Millisecond {
+			found := "not found"
+
+			if consistency.Previous != nil {
+				found = "stale"
+			}
+
+			diags.AddError(
+				"Resource Not Consistent",
+				fmt.Sprintf("%q was still %s after %d polls over %dms, exceeding the consistency_timeout of %dms", input, found, polls, elapsed.Milliseconds(), r.client.ConsistencyTimeout),
+			)
+
+			return diags
+		}
+
+		// Client reads the resource back until it sees the write
+		diags.Append(sleep(ctx, r.client, r.lagPoint("Consistency Poll", "resource_consistency_poll", input), interval)...)
+
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	return diags
+}
+
+func setLagResourceConsistency(ctx context.Context, setKey func(context.Context, string, []byte) diag.Diagnostics, consistency *lagResourceConsistency) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	// Clear the window once there is none
+	if consistency == nil {
+		return setKey(ctx, lagResourcePrivateConsistencyKey, nil)
+	}
+
+	value, err := json.Marshal(consistency)
+	if err != nil {
+		diags.AddError(
+			"Unable to Save Private Data",
+			fmt.Sprintf("Unable to encode the resource consistency window: %s", err),
+		)
+
+		return diags
+	}
+
+	return setKey(ctx, lagResourcePrivateConsistencyKey, value)
+}
+
+func getLagResourceConsistency(ctx context.Context, getKey func(context.Context, string) ([]byte, diag.Diagnostics)) (*lagResourceConsistency, diag.Diagnostics) {
+	value, diags := getKey(ctx, lagResourcePrivateConsistencyKey)
+
+	if diags.HasError() || value == nil {
+		return nil, diags
+	}
+
+	var consistency lagResourceConsistency
+
+	if err := json.Unmarshal(value, &consistency); err != nil {
+		diags.AddError(
+			"Unable to Read Private Data",
+			fmt.Sprintf("Unable to decode the resource consistency window: %s", err),
+		)
+
+		return nil, diags
+	}
+
+	return &consistency, diags
+}
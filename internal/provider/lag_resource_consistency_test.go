@@ -0,0 +1,188 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testCreateLagResource creates a lag resource with the given attribute
+// values, returning the response, which holds its state, identity and private
+// state, along with its diagnostics.
+func testCreateLagResource(t *testing.T, server tfprotov6.ProviderServer, stateType tftypes.Object, values map[string]tftypes.Value) (*tfprotov6.ApplyResourceChangeResponse, []string) {
+	config := map[string]tftypes.Value{}
+	planned := map[string]tftypes.Value{}
+
+	for name, attributeType := range stateType.AttributeTypes {
+		config[name] = tftypes.NewValue(attributeType, nil)
+		planned[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	for name, value := range values {
+		config[name] = value
+		planned[name] = value
+	}
+
+	for _, name := range []string{"id", "output", "retries", "computed_outputs", "payload_output"} {
+		planned[name] = tftypes.NewValue(stateType.AttributeTypes[name], tftypes.UnknownValue)
+	}
+
+	configValue, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plannedValue, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, planned))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priorValue, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     "testlagger_lag",
+		PriorState:   &priorValue,
+		PlannedState: &plannedValue,
+		Config:       &configValue,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errors []string
+
+	for _, diagnostic := range resp.Diagnostics {
+		errors = append(errors, diagnostic.Summary+": "+diagnostic.Detail)
+	}
+
+	return resp, errors
+}
+
+func testConsistentLagResource(input string, consistencyDelay int64) map[string]tftypes.Value {
+	return map[string]tftypes.Value{
+		"input":             tftypes.NewValue(tftypes.String, input),
+		"consistency_delay": tftypes.NewValue(tftypes.Number, consistencyDelay),
+	}
+}
+
+func TestLagResource_ReadConsistency(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	created, errors := testCreateLagResource(t, server, stateType, testConsistentLagResource("one", 200))
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	read := func() tftypes.Value {
+		resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+			TypeName:        "testlagger_lag",
+			CurrentState:    created.NewState,
+			CurrentIdentity: created.NewIdentity,
+			Private:         created.Private,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, diagnostic := range resp.Diagnostics {
+			t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+
+		newState, err := resp.NewState.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return newState
+	}
+
+	// Reads right after the create find nothing
+	if state := read(); !state.IsNull() {
+		t.Fatalf("expected a read within the consistency delay to find nothing, got %s", state)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if state := read(); state.IsNull() {
+		t.Fatal("expected a read after the consistency delay to find the resource")
+	}
+}
+
+func TestLagResource_ReadConsistencyNullIdentity(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	created, errors := testCreateLagResource(t, server, stateType, testConsistentLagResource("one", 60000))
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	// A read within the consistency delay of state without an identity, as
+	// when upgraded from a version before identity existed
+	resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     "testlagger_lag",
+		CurrentState: created.NewState,
+		Private:      created.Private,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	state, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !state.IsNull() {
+		t.Errorf("expected a read within the consistency delay to find nothing, got %s", state)
+	}
+}
+
+func TestLagResource_WaitForConsistency(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"consistency_poll_interval": tftypes.NewValue(tftypes.Number, 50),
+	})
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	start := time.Now()
+
+	if _, errors := testCreateLagResource(t, server, stateType, testConsistentLagResource("one", 200)); len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	// The create polls until reads are consistent
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the create to wait out the consistency delay of 200ms, took %s", elapsed)
+	}
+
+	server, _ = testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"consistency_poll_interval": tftypes.NewValue(tftypes.Number, 50),
+		"consistency_timeout":       tftypes.NewValue(tftypes.Number, 120),
+	})
+
+	_, errors := testCreateLagResource(t, server, stateType, testConsistentLagResource("one", 1000))
+
+	if len(errors) != 1 || !strings.HasPrefix(errors[0], `Resource Not Consistent: "one" was still not found after 2 polls`) {
+		t.Errorf("expected the create to time out after 2 polls, got %v", errors)
+	}
+}
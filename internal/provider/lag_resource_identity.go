@@ -0,0 +1,45 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithIdentity = &LagResource{}
+
+// lagResourceIdentityModel describes the identity of a lag resource.
+type lagResourceIdentityModel struct {
+	Namespace types.String `tfsdk:"namespace"`
+	Input     types.String `tfsdk:"input"`
+}
+
+func (r *LagResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"namespace": identityschema.StringAttribute{
+				Description:       "Namespace of the resource",
+				OptionalForImport: true,
+			},
+			"input": identityschema.StringAttribute{
+				Description:       "Input string echoed by the resource",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// identity returns the identity of the resource with this state.
+func (m LagResourceModel) identity() lagResourceIdentityModel {
+	return lagResourceIdentityModel{
+		Namespace: m.Namespace,
+		Input:     m.Input,
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestLagResource_Identity(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_12_0),
+		},
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	namespace = "team"
+	input = "one"
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectIdentity("testlagger_lag.test", map[string]knownvalue.Check{
+						"namespace": knownvalue.StringExact("team"),
+						"input":     knownvalue.StringExact("one"),
+					}),
+				},
+			},
+			{
+				ResourceName:    "testlagger_lag.test",
+				ImportState:     true,
+				ImportStateKind: resource.ImportBlockWithResourceIdentity,
+			},
+		},
+	})
+}
+
+func TestLagResource_ImportStateByIdentity(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"resource_identity_delay": tftypes.NewValue(tftypes.Number, 50),
+	})
+
+	identitySchemas, err := server.GetResourceIdentitySchemas(context.Background(), &tfprotov6.GetResourceIdentitySchemasRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identityType := identitySchemas.IdentitySchemas["testlagger_lag"].ValueType()
+
+	identity, err := tfprotov6.NewDynamicValue(identityType, tftypes.NewValue(identityType, map[string]tftypes.Value{
+		"namespace": tftypes.NewValue(tftypes.String, "team"),
+		"input":     tftypes.NewValue(tftypes.String, "hello"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	resp, err := server.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{
+		TypeName: "testlagger_lag",
+		Identity: &tfprotov6.ResourceIdentityData{IdentityData: &identity},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the import to take at least 50ms, took %s", elapsed)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	if len(resp.ImportedResources) != 1 {
+		t.Fatalf("expected one imported resource, got %d", len(resp.ImportedResources))
+	}
+
+	imported, err := resp.ImportedResources[0].State.Unmarshal(schemaResp.ResourceSchemas["testlagger_lag"].ValueType())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := imported.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"id": "hello", "namespace": "team", "input": "hello", "output": "hello"} {
+		var got string
+		if err := attributes[name].As(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("expected %s to be %q, got %q", name, want, got)
+		}
+	}
+
+	if resp.ImportedResources[0].Identity == nil {
+		t.Fatal("expected the imported resource to have an identity")
+	}
+
+	importedIdentity, err := resp.ImportedResources[0].Identity.IdentityData.Unmarshal(identityType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !importedIdentity.Equal(tftypes.NewValue(identityType, map[string]tftypes.Value{
+		"namespace": tftypes.NewValue(tftypes.String, "team"),
+		"input":     tftypes.NewValue(tftypes.String, "hello"),
+	})) {
+		t.Errorf("expected the identity to be kept, got %s", importedIdentity)
+	}
+}
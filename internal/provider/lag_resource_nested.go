@@ -0,0 +1,341 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxNestedDepth is the number of levels of the nested attributes and blocks
+// of the lag resource.
+const maxNestedDepth = 3
+
+// maxNestedObjects is the largest number of objects generated across the
+// nested attributes of the lag resource, which bounds the memory used to
+// generate them, as they are generated whole.
+const maxNestedObjects = 100000
+
+// nestedObjectType returns the type of the objects at the given level of the
+// nested attributes and blocks, counting from 1, whose children are the
+// objects of the next level.
+func nestedObjectType(level int) types.ObjectType {
+	attributeTypes := map[string]attr.Type{
+		"name":  types.StringType,
+		"value": types.StringType,
+	}
+
+	if level < maxNestedDepth {
+		attributeTypes["children"] = types.ListType{ElemType: nestedObjectType(level + 1)}
+	}
+
+	return types.ObjectType{AttrTypes: attributeTypes}
+}
+
+// nestedAttributeObject returns the schema of the objects at the given level
+// of the generated nested attributes.
+func nestedAttributeObject(level int) schema.NestedAttributeObject {
+	attributes := map[string]schema.Attribute{
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Position of the object, such as `1.0.2`",
+			Computed:            true,
+		},
+		"value": schema.StringAttribute{
+			MarkdownDescription: "The input followed by the kind of attribute and the name",
+			Computed:            true,
+		},
+	}
+
+	if level < maxNestedDepth {
+		attributes["children"] = schema.ListNestedAttribute{
+			MarkdownDescription: fmt.Sprintf("`nested_breadth` objects of level %d, when the `nested_depth` is more than %d", level+1, level),
+			Computed:            true,
+			NestedObject:        nestedAttributeObject(level + 1),
+		}
+	}
+
+	return schema.NestedAttributeObject{Attributes: attributes}
+}
+
+// nestedBlockObject returns the schema of the objects at the given level of
+// the nested blocks.
+func nestedBlockObject(level int) schema.NestedBlockObject {
+	object := schema.NestedBlockObject{
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the block",
+				Required:            true,
+			},
+			"value": schema.StringAttribute{
+				MarkdownDescription: "Value of the block",
+				Optional:            true,
+			},
+		},
+	}
+
+	if level < maxNestedDepth {
+		object.Blocks = map[string]schema.Block{
+			"children": schema.ListNestedBlock{
+				MarkdownDescription: fmt.Sprintf("Blocks of level %d", level+1),
+				NestedObject:        nestedBlockObject(level + 1),
+			},
+		}
+	}
+
+	return object
+}
+
+// lagResourceNestedAttributes returns the generated nested attributes of the
+// lag resource.
+func lagResourceNestedAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"nested_depth": schema.Int64Attribute{
+			MarkdownDescription: fmt.Sprintf("Number of levels of objects generated in `list_nested`, `set_nested` and `map_nested`, from 1 to %d. Defaults to 1 when `nested_breadth` or `nested_set_cardinality` is set", maxNestedDepth),
+			Optional:            true,
+		},
+		"nested_breadth": schema.Int64Attribute{
+			MarkdownDescription: fmt.Sprintf("Number of objects generated in `list_nested` and `map_nested`, and of children generated for each object. Defaults to 1 when `nested_depth` or `nested_set_cardinality` is set. The nested attributes can have at most %d objects in all", maxNestedObjects),
+			Optional:            true,
+		},
+		"nested_set_cardinality": schema.Int64Attribute{
+			MarkdownDescription: fmt.Sprintf("Number of objects generated in `set_nested`. Defaults to `nested_breadth`. The nested attributes can have at most %d objects in all", maxNestedObjects),
+			Optional:            true,
+		},
+		"list_nested": schema.ListNestedAttribute{
+			MarkdownDescription: "Objects generated from `nested_depth` and `nested_breadth`, known when planning so that changes to the input are diffed throughout",
+			Computed:            true,
+			NestedObject:        nestedAttributeObject(1),
+		},
+		"set_nested": schema.SetNestedAttribute{
+			MarkdownDescription: "Objects generated from `nested_depth`, `nested_breadth` and `nested_set_cardinality`, known when planning",
+			Computed:            true,
+			NestedObject:        nestedAttributeObject(1),
+		},
+		"map_nested": schema.MapNestedAttribute{
+			MarkdownDescription: "Objects generated from `nested_depth` and `nested_breadth`, keyed by their name, known when planning",
+			Computed:            true,
+			NestedObject:        nestedAttributeObject(1),
+		},
+	}
+}
+
+// lagResourceNestedBlocks returns the nested blocks of the lag resource.
+func lagResourceNestedBlocks() map[string]schema.Block {
+	return map[string]schema.Block{
+		"list_block": schema.ListNestedBlock{
+			MarkdownDescription: fmt.Sprintf("Blocks kept in state as configured, with `children` blocks up to %d levels deep", maxNestedDepth),
+			NestedObject:        nestedBlockObject(1),
+		},
+		"set_block": schema.SetNestedBlock{
+			MarkdownDescription: fmt.Sprintf("Blocks kept in state as configured, with `children` blocks up to %d levels deep", maxNestedDepth),
+			NestedObject:        nestedBlockObject(1),
+		},
+	}
+}
+
+// nestedShape returns the depth, breadth and set cardinality of the nested
+// attributes of the resource, and whether any of them is set.
+func (m LagResourceModel) nestedShape() (int, int, int, bool) {
+	if m.NestedDepth.IsNull() && m.NestedBreadth.IsNull() && m.NestedSetCardinality.IsNull() {
+		return 0, 0, 0, false
+	}
+
+	depth, breadth := 1, 1
+
+	if !m.NestedDepth.IsNull() {
+		depth = int(m.NestedDepth.ValueInt64())
+	}
+
+	if !m.NestedBreadth.IsNull() {
+		breadth = int(m.NestedBreadth.ValueInt64())
+	}
+
+	cardinality := breadth
+
+	if !m.NestedSetCardinality.IsNull() {
+		cardinality = int(m.NestedSetCardinality.ValueInt64())
+	}
+
+	return depth, breadth, cardinality, true
+}
+
+// nestedObjectCount returns the number of objects generated across the
+// nested attributes of the given shape, or maxNestedObjects+1 once there are
+// more than maxNestedObjects.
+func nestedObjectCount(depth int, breadth int, cardinality int) int64 {
+	if breadth > maxNestedObjects || cardinality > maxNestedObjects {
+		return maxNestedObjects + 1
+	}
+
+	// Objects below each object of the first level, itself included
+	below := int64(1)
+	level := int64(1)
+
+	for i := 1; i < depth; i++ {
+		level *= int64(breadth)
+		below += level
+
+		if below > maxNestedObjects {
+			return maxNestedObjects + 1
+		}
+	}
+
+	// The list and map have breadth objects at the first level, and the set
+	// cardinality objects
+	count := 2*below*int64(breadth) + below*int64(cardinality)
+
+	if count > maxNestedObjects {
+		return maxNestedObjects + 1
+	}
+
+	return count
+}
+
+// setNested sets the generated nested attributes of the resource from its
+// input and nested shape.
+func (m *LagResourceModel) setNested() {
+	objectType := nestedObjectType(1)
+
+	depth, breadth, cardinality, ok := m.nestedShape()
+	if !ok {
+		m.ListNested = types.ListNull(objectType)
+		m.SetNested = types.SetNull(objectType)
+		m.MapNested = types.MapNull(objectType)
+		return
+	}
+
+	input := m.Input.ValueString()
+
+	m.ListNested = types.ListValueMust(objectType, nestedObjects(input+"/list", "", 1, depth, breadth, breadth))
+	m.SetNested = types.SetValueMust(objectType, nestedObjects(input+"/set", "", 1, depth, breadth, cardinality))
+
+	objects := map[string]attr.Value{}
+
+	for _, object := range nestedObjects(input+"/map", "", 1, depth, breadth, breadth) {
+		name := object.(types.Object).Attributes()["name"].(types.String)
+		objects[name.ValueString()] = object
+	}
+
+	m.MapNested = types.MapValueMust(objectType, objects)
+}
+
+// nestedObjects returns count objects at the given level, named after their
+// parent, each with breadth children while the level is less than depth.
+func nestedObjects(prefix string, parent string, level int, depth int, breadth int, count int) []attr.Value {
+	objectType := nestedObjectType(level)
+	objects := make([]attr.Value, 0, count)
+
+	for i := 0; i < count; i++ {
+		name := strconv.Itoa(i)
+		if parent != "" {
+			name = parent + "." + name
+		}
+
+		attributes := map[string]attr.Value{
+			"name":  types.StringValue(name),
+			"value": types.StringValue(prefix + "/" + name),
+		}
+
+		if level < maxNestedDepth {
+			childType := nestedObjectType(level + 1)
+
+			if level < depth {
+				attributes["children"] = types.ListValueMust(childType, nestedObjects(prefix, name, level+1, depth, breadth, breadth))
+			} else {
+				attributes["children"] = types.ListNull(childType)
+			}
+		}
+
+		objects = append(objects, types.ObjectValueMust(objectType.AttrTypes, attributes))
+	}
+
+	return objects
+}
+
+// planLagResourceNested plans the generated nested attributes of a lag
+// resource being created or updated, which are known whenever its input and
+// nested shape are, so that plans render their differences in full.
+func planLagResourceNested(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing is planned when destroying
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan LagResourceModel
+
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Input.IsUnknown() || plan.NestedDepth.IsUnknown() || plan.NestedBreadth.IsUnknown() || plan.NestedSetCardinality.IsUnknown() {
+		return
+	}
+
+	if !plan.ListNested.IsUnknown() && !plan.SetNested.IsUnknown() && !plan.MapNested.IsUnknown() {
+		return
+	}
+
+	plan.setNested()
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// validateNested checks the nested shape attributes of the configuration.
+func validateNested(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	check := func(name string, min int64, max int64) types.Int64 {
+		var value types.Int64
+
+		diags.Append(config.GetAttribute(ctx, path.Root(name), &value)...)
+
+		if value.IsNull() || value.IsUnknown() {
+			return value
+		}
+
+		if value.ValueInt64() < min || (max > 0 && value.ValueInt64() > max) {
+			message := fmt.Sprintf("%s must be at least %d, got %d", name, min, value.ValueInt64())
+			if max > 0 {
+				message = fmt.Sprintf("%s must be from %d to %d, got %d", name, min, max, value.ValueInt64())
+			}
+
+			diags.AddAttributeError(path.Root(name), "Invalid Nested Shape", message)
+		}
+
+		return value
+	}
+
+	shape := LagResourceModel{
+		NestedDepth:          check("nested_depth", 1, maxNestedDepth),
+		NestedBreadth:        check("nested_breadth", 0, 0),
+		NestedSetCardinality: check("nested_set_cardinality", 0, 0),
+	}
+
+	if diags.HasError() || shape.NestedDepth.IsUnknown() || shape.NestedBreadth.IsUnknown() || shape.NestedSetCardinality.IsUnknown() {
+		return diags
+	}
+
+	if depth, breadth, cardinality, ok := shape.nestedShape(); ok && nestedObjectCount(depth, breadth, cardinality) > maxNestedObjects {
+		diags.AddAttributeError(
+			path.Root("nested_breadth"),
+			"Invalid Nested Shape",
+			fmt.Sprintf("nested_depth %d, nested_breadth %d and nested_set_cardinality %d generate more than %d objects", depth, breadth, cardinality, maxNestedObjects),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,220 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestLagResourceModel_SetNested(t *testing.T) {
+	model := newLagResourceModel("hello", types.StringValue("hello"), types.StringValue("hello"))
+	model.NestedDepth = types.Int64Value(2)
+	model.NestedBreadth = types.Int64Value(3)
+	model.NestedSetCardinality = types.Int64Value(5)
+
+	model.setNested()
+
+	if got := len(model.ListNested.Elements()); got != 3 {
+		t.Fatalf("expected 3 objects in list_nested, got %d", got)
+	}
+
+	if got := len(model.SetNested.Elements()); got != 5 {
+		t.Errorf("expected 5 objects in set_nested, got %d", got)
+	}
+
+	if got := len(model.MapNested.Elements()); got != 3 {
+		t.Errorf("expected 3 objects in map_nested, got %d", got)
+	}
+
+	first := model.ListNested.Elements()[0].(types.Object).Attributes()
+	children := first["children"].(types.List)
+
+	if len(children.Elements()) != 3 {
+		t.Fatalf("expected 3 children of the first object, got %s", children)
+	}
+
+	child := children.Elements()[2].(types.Object).Attributes()
+
+	if child["name"].(types.String).ValueString() != "0.2" || child["value"].(types.String).ValueString() != "hello/list/0.2" {
+		t.Errorf("expected the last child to be named 0.2 with value hello/list/0.2, got %s and %s", child["name"], child["value"])
+	}
+
+	if !child["children"].IsNull() {
+		t.Errorf("expected no children below the nested depth, got %s", child["children"])
+	}
+
+	model.NestedDepth = types.Int64Null()
+	model.NestedBreadth = types.Int64Null()
+	model.NestedSetCardinality = types.Int64Null()
+
+	model.setNested()
+
+	if !model.ListNested.IsNull() || !model.SetNested.IsNull() || !model.MapNested.IsNull() {
+		t.Errorf("expected null nested attributes without a nested shape, got %s, %s and %s", model.ListNested, model.SetNested, model.MapNested)
+	}
+}
+
+func TestLagResource_PlanNested(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	planned := testPlanLagResource(t, server, stateType, nil, map[string]tftypes.Value{
+		"input":                  tftypes.NewValue(tftypes.String, "one"),
+		"nested_depth":           tftypes.NewValue(tftypes.Number, 3),
+		"nested_breadth":         tftypes.NewValue(tftypes.Number, 2),
+		"nested_set_cardinality": tftypes.NewValue(tftypes.Number, 4),
+	})
+
+	for name, want := range map[string]int{"list_nested": 2, "set_nested": 4, "map_nested": 2} {
+		if !planned[name].IsFullyKnown() {
+			t.Errorf("expected %s to be known when planning, got %s", name, planned[name])
+			continue
+		}
+
+		var objects []tftypes.Value
+		if name == "map_nested" {
+			var elements map[string]tftypes.Value
+			if err := planned[name].As(&elements); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, element := range elements {
+				objects = append(objects, element)
+			}
+		} else if err := planned[name].As(&objects); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(objects) != want {
+			t.Errorf("expected %d objects in %s, got %d", want, name, len(objects))
+		}
+	}
+}
+
+func TestLagResource_ValidateNested(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	for name, test := range map[string]struct {
+		shape map[string]int
+		valid bool
+	}{
+		"depth above the maximum":  {shape: map[string]int{"nested_depth": maxNestedDepth + 1}},
+		"breadth too large":        {shape: map[string]int{"nested_depth": 3, "nested_breadth": 1000}},
+		"cardinality too large":    {shape: map[string]int{"nested_set_cardinality": maxNestedObjects}},
+		"breadth within the limit": {shape: map[string]int{"nested_depth": 2, "nested_breadth": 100, "nested_set_cardinality": 0}, valid: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range stateType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+
+			for name, value := range test.shape {
+				attributes[name] = tftypes.NewValue(tftypes.Number, value)
+			}
+
+			config, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{
+				TypeName: "testlagger_lag",
+				Config:   &config,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.valid {
+				if len(resp.Diagnostics) != 0 {
+					t.Errorf("expected %v to be valid, got %v", test.shape, resp.Diagnostics)
+				}
+
+				return
+			}
+
+			if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Summary != "Invalid Nested Shape" {
+				t.Errorf("expected %v to be invalid, got %v", test.shape, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestNestedObjectCount(t *testing.T) {
+	model := newLagResourceModel("hello", types.StringValue("hello"), types.StringValue("hello"))
+	model.NestedDepth = types.Int64Value(3)
+	model.NestedBreadth = types.Int64Value(3)
+	model.NestedSetCardinality = types.Int64Value(2)
+
+	model.setNested()
+
+	// Each object of the first level has 3 children with 3 children each
+	want := int64((len(model.ListNested.Elements()) + len(model.SetNested.Elements()) + len(model.MapNested.Elements())) * (1 + 3 + 9))
+
+	if got := nestedObjectCount(3, 3, 2); got != want {
+		t.Errorf("expected %d objects, got %d", want, got)
+	}
+
+	if got := nestedObjectCount(3, 1<<40, 1); got != maxNestedObjects+1 {
+		t.Errorf("expected the count to stop above %d, got %d", maxNestedObjects, got)
+	}
+}
+
+func TestLagResource_NestedBlocks(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "testlagger_lag" "test" {
+					input          = "testvalue"
+					create_delay   = 100
+					nested_depth   = 2
+					nested_breadth = 3
+
+					list_block {
+						name = "first"
+
+						children {
+							name  = "child"
+							value = "one"
+						}
+					}
+
+					dynamic "set_block" {
+						for_each = range(4)
+
+						content {
+							name = "set-${set_block.value}"
+						}
+					}
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("list_block").AtSliceIndex(0).AtMapKey("children").AtSliceIndex(0).AtMapKey("value"), knownvalue.StringExact("one")),
+					statecheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("set_block"), knownvalue.SetSizeExact(4)),
+					statecheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("list_nested").AtSliceIndex(1).AtMapKey("children"), knownvalue.ListSizeExact(3)),
+					statecheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("map_nested").AtMapKey("2").AtMapKey("value"), knownvalue.StringExact("testvalue/map/2")),
+				},
+			},
+		},
+	})
+}
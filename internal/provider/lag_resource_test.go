@@ -2,9 +2,13 @@ package provider
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 )
 
 func TestLagResource(t *testing.T) {
@@ -28,14 +32,9 @@ func TestLagResource(t *testing.T) {
 			{
 				ResourceName:                         "testlagger_lag.test",
 				ImportState:                          true,
+				ImportStateId:                        "one;create=1000;read=1000;update=1000;delete=1000",
 				ImportStateVerify:                    true,
 				ImportStateVerifyIdentifierAttribute: "id",
-				ImportStateVerifyIgnore: []string{
-					"create_delay",
-					"delete_delay",
-					"read_delay",
-					"update_delay",
-				},
 			},
 			// Update and Read testing
 			{
@@ -60,3 +59,411 @@ resource "testlagger_lag" "test" {
 }
 `, createDelay, readDelay, updateDelay, deleteDelay, input)
 }
+
+func TestLagResource_DelayDistributions(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	delay_distributions = {
+		create = {
+			distribution = "uniform"
+			min          = 100
+			max          = 200
+			seed         = 42
+		}
+		read = {
+			distribution = "normal"
+			mean         = 100
+			stddev       = 20
+		}
+		delete = {
+			distribution = "exponential"
+			mean         = 100
+			max          = 500
+		}
+	}
+	input = "one"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("testlagger_lag.test", "delay_distributions.create.distribution", "uniform"),
+					resource.TestCheckResourceAttr("testlagger_lag.test", "delay_distributions.create.seed", "42"),
+					resource.TestCheckResourceAttr("testlagger_lag.test", "output", "one"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagResource_PlanDelay(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "testlagger" {
+	resource_plan_delay = 100
+}
+
+resource "testlagger_lag" "default" {
+	input = "one"
+}
+
+resource "testlagger_lag" "test" {
+	plan_delay = 200
+	input      = "two"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("testlagger_lag.default", "plan_delay"),
+					resource.TestCheckResourceAttr("testlagger_lag.test", "plan_delay", "200"),
+					resource.TestCheckResourceAttr("testlagger_lag.test", "output", "two"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagResource_ValidateDelay(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	validate_delay   = 100
+	validate_warning = "simulated validation warning"
+	input            = "one"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("testlagger_lag.test", "validate_delay", "100"),
+					resource.TestCheckResourceAttr("testlagger_lag.test", "output", "one"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagResource_ValidateError(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	validate_error = {
+		message = "simulated validation failure"
+	}
+	input = "one"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`simulated validation failure`),
+			},
+		},
+	})
+}
+
+func TestLagResource_InvalidDelayDistribution(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	delay_distributions = {
+		create = {
+			distribution = "uniform"
+			min          = 100
+		}
+	}
+	input = "one"
+}
+`,
+				ExpectError: regexp.MustCompile(`uniform distribution requires both min and max`),
+			},
+		},
+	})
+}
+
+func TestLagResource_CreateError(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	create_delay = 100
+	create_error = {
+		message = "simulated create failure"
+	}
+	input = "one"
+}
+`,
+				ExpectError: regexp.MustCompile(`simulated create failure`),
+			},
+		},
+	})
+}
+
+func TestLagResource_UpdateError(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testLagResourceConfig(100, 100, 100, 100, "one"),
+			},
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	update_error = {
+		message = "simulated update failure"
+		on_call = 1
+	}
+	input = "two"
+}
+`,
+				ExpectError: regexp.MustCompile(`simulated update failure`),
+			},
+			// The failed update leaves the prior state in place
+			{
+				Config:   testLagResourceConfig(100, 100, 100, 100, "one"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestLagResource_CreateJob(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	create_job = {
+		poll_interval = 50
+		poll_count    = 2
+	}
+	delete_job = {
+		poll_interval = 50
+		duration      = 100
+	}
+	input = "one"
+}
+`,
+				Check: resource.TestCheckResourceAttr("testlagger_lag.test", "output", "one"),
+			},
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	update_job = {
+		poll_interval       = 50
+		poll_count          = 1
+		failure_probability = 1
+	}
+	input = "two"
+}
+`,
+				ExpectError: regexp.MustCompile(`Update Job Failed`),
+			},
+		},
+	})
+}
+
+func TestLagResource_ReplaceTrigger(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testLagResourceReplaceConfig("a", false, "one"),
+			},
+			{
+				Config: testLagResourceReplaceConfig("b", false, "one"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("testlagger_lag.test", plancheck.ResourceActionReplace),
+					},
+				},
+			},
+			// The input is updated in place unless replace_on_input_change is set
+			{
+				Config: testLagResourceReplaceConfig("b", false, "two"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("testlagger_lag.test", plancheck.ResourceActionUpdate),
+					},
+				},
+			},
+			{
+				Config: testLagResourceReplaceConfig("b", true, "three"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("testlagger_lag.test", plancheck.ResourceActionReplace),
+					},
+				},
+			},
+		},
+	})
+}
+
+func testLagResourceReplaceConfig(replaceTrigger string, replaceOnInputChange bool, input string) string {
+	return fmt.Sprintf(`
+resource "testlagger_lag" "test" {
+	replace_trigger = %q
+	replace_on_input_change = %t
+	input = %q
+}
+`, replaceTrigger, replaceOnInputChange, input)
+}
+
+func TestLagResource_CreateBeforeDestroy(t *testing.T) {
+	dir := t.TempDir()
+
+	config := func(replaceTrigger string) string {
+		return fmt.Sprintf(`
+provider "testlagger" {
+	remote_store_path = %q
+}
+
+resource "testlagger_lag" "test" {
+	create_delay = 100
+	delete_delay = 100
+	replace_trigger = %q
+	input = "one"
+
+	lifecycle {
+		create_before_destroy = true
+	}
+}
+`, dir, replaceTrigger)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("a"),
+			},
+			// Destroying the original keeps the object of its replacement, so
+			// the replacement is still found when refreshed
+			{
+				Config: config("b"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("testlagger_lag.test", plancheck.ResourceActionCreateBeforeDestroy),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestLagResource_ComputedOutputs(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	computed_output_keys = ["a", "b"]
+	input = "one"
+}
+
+# The keys of computed_outputs are known during plan
+resource "testlagger_lag" "each" {
+	for_each = testlagger_lag.test.computed_outputs
+	input = provider::testlagger::lag(10, each.value)
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("testlagger_lag.test", "computed_outputs.a", "one/a"),
+					resource.TestCheckResourceAttr("testlagger_lag.each[\"b\"]", "output", "one/b"),
+				),
+			},
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	computed_output_keys = ["a", "b"]
+	use_state_for_unknown = true
+	read_delay = 10
+	input = "one"
+}
+`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("output"), knownvalue.StringExact("one")),
+						plancheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("computed_outputs").AtMapKey("b"), knownvalue.StringExact("one/b")),
+					},
+				},
+			},
+			{
+				Config: `
+resource "testlagger_lag" "test" {
+	computed_output_keys = ["a", "b"]
+	defer_known_until_apply = true
+	input = "two"
+}
+`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownValue("testlagger_lag.test", tfjsonpath.New("computed_outputs")),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestParseLagResourceImportID(t *testing.T) {
+	model, err := parseLagResourceImportID("hello;create=100;read=50;namespace=team")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model.Id.ValueString() != "hello" || model.Input.ValueString() != "hello" || model.Output.ValueString() != "hello" {
+		t.Errorf("expected id, input and output to be hello, got %s, %s and %s", model.Id, model.Input, model.Output)
+	}
+
+	if model.Namespace.ValueString() != "team" {
+		t.Errorf("expected namespace to be team, got %s", model.Namespace)
+	}
+
+	if model.CreateDelay.ValueInt64() != 100 || model.ReadDelay.ValueInt64() != 50 {
+		t.Errorf("expected create and read delays of 100 and 50, got %s and %s", model.CreateDelay, model.ReadDelay)
+	}
+
+	if !model.UpdateDelay.IsNull() || !model.DeleteDelay.IsNull() {
+		t.Errorf("expected update and delete delays to be null, got %s and %s", model.UpdateDelay, model.DeleteDelay)
+	}
+
+	model, err = parseLagResourceImportID("hello;defer_when=always")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model.DeferWhen.ValueString() != DeferWhenAlways {
+		t.Errorf("expected defer_when to be always, got %s", model.DeferWhen)
+	}
+
+	for _, id := range []string{"hello;create", "hello;sleep=100", "hello;read=-1", "hello;read=soon", "hello;defer_when=resource_config_unknown"} {
+		if _, err := parseLagResourceImportID(id); err == nil {
+			t.Errorf("expected an error for %q", id)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// planLagResourceUnknowns plans which computed attributes of a lag resource
+// being created or updated are unknown until apply. By default the framework
+// plans every computed attribute as unknown whenever the resource changes.
+// With use_state_for_unknown, those that cannot change keep their prior
+// values, as does payload_output while the payload is unchanged, and
+// computed_outputs is planned with known keys and unknown values unless
+// defer_known_until_apply is set.
+func planLagResourceUnknowns(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing is planned when destroying
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan LagResourceModel
+
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !req.State.Raw.IsNull() && plan.UseStateForUnknown.ValueBool() {
+		var state LagResourceModel
+
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// The id and outputs follow the input
+		if plan.Input.Equal(state.Input) {
+			if plan.Id.IsUnknown() {
+				plan.Id = state.Id
+			}
+
+			if plan.Output.IsUnknown() {
+				plan.Output = state.Output
+			}
+
+			if plan.ComputedOutputs.IsUnknown() && plan.ComputedOutputKeys.Equal(state.ComputedOutputKeys) {
+				plan.ComputedOutputs = state.ComputedOutputs
+			}
+
+			if plan.PayloadOutput.IsUnknown() && plan.Payload.Equal(state.Payload) && plan.PayloadSize.Equal(state.PayloadSize) {
+				plan.PayloadOutput = state.PayloadOutput
+			}
+		}
+	}
+
+	if plan.ComputedOutputs.IsUnknown() && !plan.DeferKnownUntilApply.ValueBool() && !plan.ComputedOutputKeys.IsUnknown() {
+		if plan.ComputedOutputKeys.IsNull() {
+			plan.ComputedOutputs = types.MapNull(types.StringType)
+		} else {
+			elements := map[string]attr.Value{}
+
+			for _, key := range plan.ComputedOutputKeys.Elements() {
+				if key, ok := key.(types.String); ok && !key.IsUnknown() {
+					elements[key.ValueString()] = types.StringUnknown()
+				}
+			}
+
+			// A key that is not yet known leaves every key unknown
+			if len(elements) == len(plan.ComputedOutputKeys.Elements()) {
+				plan.ComputedOutputs = types.MapValueMust(types.StringType, elements)
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// computedOutputs returns the computed outputs of the resource, which are
+// its input followed by each of its computed output keys.
+func (m LagResourceModel) computedOutputs(ctx context.Context) (types.Map, diag.Diagnostics) {
+	if m.ComputedOutputKeys.IsNull() {
+		return types.MapNull(types.StringType), nil
+	}
+
+	var keys []string
+
+	diags := m.ComputedOutputKeys.ElementsAs(ctx, &keys, false)
+
+	if diags.HasError() {
+		return types.MapNull(types.StringType), diags
+	}
+
+	outputs := map[string]string{}
+
+	for _, key := range keys {
+		outputs[key] = m.Input.ValueString() + "/" + key
+	}
+
+	value, valueDiags := types.MapValueFrom(ctx, types.StringType, outputs)
+	diags.Append(valueDiags...)
+
+	return value, diags
+}
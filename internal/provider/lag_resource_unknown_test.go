@@ -0,0 +1,199 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testPlanLagResource plans a lag resource with the given attribute values
+// from its prior state, which is nil when creating it, returning the planned
+// attribute values.
+func testPlanLagResource(t *testing.T, server tfprotov6.ProviderServer, stateType tftypes.Object, prior *tfprotov6.DynamicValue, values map[string]tftypes.Value) map[string]tftypes.Value {
+	config := map[string]tftypes.Value{}
+	proposed := map[string]tftypes.Value{}
+
+	for name, attributeType := range stateType.AttributeTypes {
+		config[name] = tftypes.NewValue(attributeType, nil)
+		proposed[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	priorValue := tftypes.NewValue(stateType, nil)
+
+	// Computed attributes are proposed with their prior values
+	if prior != nil {
+		var err error
+
+		priorValue, err = prior.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var priorAttributes map[string]tftypes.Value
+		if err := priorValue.As(&priorAttributes); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range []string{"id", "output", "retries", "computed_outputs", "payload_output"} {
+			proposed[name] = priorAttributes[name]
+		}
+	}
+
+	for name, value := range values {
+		config[name] = value
+		proposed[name] = value
+	}
+
+	configValue, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposedValue, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, proposed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priorState, err := tfprotov6.NewDynamicValue(stateType, priorValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "testlagger_lag",
+		PriorState:       &priorState,
+		ProposedNewState: &proposedValue,
+		Config:           &configValue,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	planned, err := resp.PlannedState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := planned.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	return attributes
+}
+
+func TestLagResource_PlanComputedOutputs(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+	keysType := stateType.AttributeTypes["computed_output_keys"]
+
+	values := map[string]tftypes.Value{
+		"input": tftypes.NewValue(tftypes.String, "one"),
+		"computed_output_keys": tftypes.NewValue(keysType, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "a"),
+			tftypes.NewValue(tftypes.String, "b"),
+		}),
+	}
+
+	// The keys are known during plan
+	planned := testPlanLagResource(t, server, stateType, nil, values)
+
+	var outputs map[string]tftypes.Value
+	if err := planned["computed_outputs"].As(&outputs); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outputs) != 2 || outputs["a"].IsKnown() || outputs["b"].IsKnown() {
+		t.Errorf("expected computed_outputs to have known keys a and b with unknown values, got %s", planned["computed_outputs"])
+	}
+
+	values["defer_known_until_apply"] = tftypes.NewValue(tftypes.Bool, true)
+
+	if planned := testPlanLagResource(t, server, stateType, nil, values); planned["computed_outputs"].IsKnown() {
+		t.Errorf("expected computed_outputs to be unknown until apply, got %s", planned["computed_outputs"])
+	}
+
+	// The values are known once applied
+	created, errors := testCreateLagResource(t, server, stateType, values)
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	state, err := created.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := state.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := attributes["computed_outputs"].As(&outputs); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"a": "one/a", "b": "one/b"} {
+		var got string
+		if err := outputs[key].As(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("expected computed output %s to be %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestLagResource_PlanUseStateForUnknown(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	for name, useStateForUnknown := range map[string]bool{"unknown": false, "prior": true} {
+		t.Run(name, func(t *testing.T) {
+			values := map[string]tftypes.Value{
+				"input":                 tftypes.NewValue(tftypes.String, "one"),
+				"use_state_for_unknown": tftypes.NewValue(tftypes.Bool, useStateForUnknown),
+				"payload_size":          tftypes.NewValue(tftypes.Number, 1),
+			}
+
+			created, errors := testCreateLagResource(t, server, stateType, values)
+			if len(errors) > 0 {
+				t.Fatal(errors)
+			}
+
+			// Changing a delay updates the resource without changing its input
+			values["read_delay"] = tftypes.NewValue(tftypes.Number, 100)
+
+			planned := testPlanLagResource(t, server, stateType, created.NewState, values)
+
+			if planned["output"].IsKnown() != useStateForUnknown || planned["id"].IsKnown() != useStateForUnknown {
+				t.Errorf("expected id and output to be known: %t, got %s and %s", useStateForUnknown, planned["id"], planned["output"])
+			}
+
+			if planned["payload_output"].IsKnown() != useStateForUnknown {
+				t.Errorf("expected payload_output to be known: %t, got %s", useStateForUnknown, planned["payload_output"])
+			}
+
+			// Changing the input leaves them unknown
+			values["input"] = tftypes.NewValue(tftypes.String, "two")
+
+			if planned := testPlanLagResource(t, server, stateType, created.NewState, values); planned["output"].IsKnown() {
+				t.Errorf("expected output to be unknown when the input changes, got %s", planned["output"])
+			}
+		})
+	}
+}
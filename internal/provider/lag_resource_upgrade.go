@@ -0,0 +1,187 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithUpgradeState = &LagResource{}
+
+// lagResourceSchemaVersion is the version of the current lag resource
+// schema. Each prior version has a StateUpgrader in UpgradeState, and a state
+// fixture in testdata/upgrade_state.
+//
+//   - 0: create_delay, read_delay, update_delay and delete_delay.
+//   - 1: a single delays map keyed by operation.
+//   - 2: the current schema.
+const lagResourceSchemaVersion = 2
+
+type lagResourceModelV0 struct {
+	Id          types.String `tfsdk:"id"`
+	CreateDelay types.Int64  `tfsdk:"create_delay"`
+	ReadDelay   types.Int64  `tfsdk:"read_delay"`
+	UpdateDelay types.Int64  `tfsdk:"update_delay"`
+	DeleteDelay types.Int64  `tfsdk:"delete_delay"`
+	Input       types.String `tfsdk:"input"`
+	Output      types.String `tfsdk:"output"`
+}
+
+// upgrade returns the state in the current schema.
+func (m lagResourceModelV0) upgrade() LagResourceModel {
+	upgraded := newLagResourceModel(m.Id.ValueString(), m.Input, m.Output)
+	upgraded.CreateDelay = m.CreateDelay
+	upgraded.ReadDelay = m.ReadDelay
+	upgraded.UpdateDelay = m.UpdateDelay
+	upgraded.DeleteDelay = m.DeleteDelay
+
+	return upgraded
+}
+
+func lagResourceSchemaV0() *schema.Schema {
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":           schema.StringAttribute{Computed: true},
+			"create_delay": schema.Int64Attribute{Optional: true},
+			"read_delay":   schema.Int64Attribute{Optional: true},
+			"update_delay": schema.Int64Attribute{Optional: true},
+			"delete_delay": schema.Int64Attribute{Optional: true},
+			"input":        schema.StringAttribute{Required: true},
+			"output":       schema.StringAttribute{Computed: true},
+		},
+	}
+}
+
+type lagResourceModelV1 struct {
+	Id     types.String           `tfsdk:"id"`
+	Delays map[string]types.Int64 `tfsdk:"delays"`
+	Input  types.String           `tfsdk:"input"`
+	Output types.String           `tfsdk:"output"`
+}
+
+// upgrade returns the state in the current schema.
+func (m lagResourceModelV1) upgrade() LagResourceModel {
+	delay := func(operation string) types.Int64 {
+		if value, ok := m.Delays[operation]; ok {
+			return value
+		}
+
+		return types.Int64Null()
+	}
+
+	upgraded := newLagResourceModel(m.Id.ValueString(), m.Input, m.Output)
+	upgraded.CreateDelay = delay("create")
+	upgraded.ReadDelay = delay("read")
+	upgraded.UpdateDelay = delay("update")
+	upgraded.DeleteDelay = delay("delete")
+
+	return upgraded
+}
+
+func lagResourceSchemaV1() *schema.Schema {
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":     schema.StringAttribute{Computed: true},
+			"delays": schema.MapAttribute{ElementType: types.Int64Type, Optional: true},
+			"input":  schema.StringAttribute{Required: true},
+			"output": schema.StringAttribute{Computed: true},
+		},
+	}
+}
+
+// newLagResourceModel returns a model with the given id, input and output,
+// and every other attribute null.
+func newLagResourceModel(id string, input types.String, output types.String) LagResourceModel {
+	return LagResourceModel{
+		Id:                 types.StringValue(id),
+		DelayDistributions: types.MapNull(delayDistributionObjectType),
+		CreateError:        types.ObjectNull(faultObjectType.AttrTypes),
+		ReadError:          types.ObjectNull(faultObjectType.AttrTypes),
+		UpdateError:        types.ObjectNull(faultObjectType.AttrTypes),
+		DeleteError:        types.ObjectNull(faultObjectType.AttrTypes),
+		ValidateError:      types.ObjectNull(faultObjectType.AttrTypes),
+		CreateJob:          types.ObjectNull(jobObjectType.AttrTypes),
+		UpdateJob:          types.ObjectNull(jobObjectType.AttrTypes),
+		DeleteJob:          types.ObjectNull(jobObjectType.AttrTypes),
+		ComputedOutputKeys: types.SetNull(types.StringType),
+		ComputedOutputs:    types.MapNull(types.StringType),
+		Payload:            types.DynamicNull(),
+		PayloadOutput:      types.DynamicNull(),
+		ListNested:         types.ListNull(nestedObjectType(1)),
+		SetNested:          types.SetNull(nestedObjectType(1)),
+		MapNested:          types.MapNull(nestedObjectType(1)),
+		ListBlock:          types.ListValueMust(nestedObjectType(1), nil),
+		SetBlock:           types.SetValueMust(nestedObjectType(1), nil),
+		Input:              input,
+		Output:             output,
+		Retries:            types.Int64Value(0),
+	}
+}
+
+func (r *LagResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: lagResourceSchemaV0(),
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var prior lagResourceModelV0
+
+				resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				resp.Diagnostics.Append(r.upgradeStateLag(ctx, prior.Input.ValueString())...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				resp.Diagnostics.Append(resp.State.Set(ctx, prior.upgrade())...)
+			},
+		},
+		1: {
+			PriorSchema: lagResourceSchemaV1(),
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var prior lagResourceModelV1
+
+				resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				resp.Diagnostics.Append(r.upgradeStateLag(ctx, prior.Input.ValueString())...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				resp.Diagnostics.Append(resp.State.Set(ctx, prior.upgrade())...)
+			},
+		},
+	}
+}
+
+// upgradeStateLag sleeps for the provider resource_upgrade_state_delay.
+func (r *LagResource) upgradeStateLag(ctx context.Context, input string) diag.Diagnostics {
+	// Prevent panic if the provider has not been configured.
+	if r.client == nil {
+		return nil
+	}
+
+	defer r.client.Concurrency.Start("resource_upgrade_state")()
+
+	upgradeStateDelay := r.client.ResourceUpgradeStateDelay.Sample("resource/upgrade_state/" + input)
+
+	// Client converts the prior state
+	return sleep(ctx, r.client, r.lagPoint("Upgrade State", "resource_upgrade_state", input), upgradeStateDelay)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testLagResourceStateFixture is the lag resource instance of a state file in
+// testdata/upgrade_state.
+type testLagResourceStateFixture struct {
+	SchemaVersion int64
+	Attributes    json.RawMessage
+}
+
+func readLagResourceStateFixture(t *testing.T, name string) testLagResourceStateFixture {
+	content, err := os.ReadFile(filepath.Join("testdata", "upgrade_state", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state struct {
+		Resources []struct {
+			Type      string `json:"type"`
+			Instances []struct {
+				SchemaVersion int64           `json:"schema_version"`
+				Attributes    json.RawMessage `json:"attributes"`
+			} `json:"instances"`
+		} `json:"resources"`
+	}
+
+	if err := json.Unmarshal(content, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, resource := range state.Resources {
+		if resource.Type == "testlagger_lag" && len(resource.Instances) == 1 {
+			return testLagResourceStateFixture{
+				SchemaVersion: resource.Instances[0].SchemaVersion,
+				Attributes:    resource.Instances[0].Attributes,
+			}
+		}
+	}
+
+	t.Fatalf("expected one testlagger_lag instance in %s", name)
+
+	return testLagResourceStateFixture{}
+}
+
+// testConfiguredProviderServer returns a provider server configured with the
+// given provider attributes, and every other attribute null.
+func testConfiguredProviderServer(t *testing.T, values map[string]tftypes.Value) (tfprotov6.ProviderServer, *tfprotov6.GetProviderSchemaResponse) {
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objectType := schemaResp.Provider.ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := values[name]; ok {
+			attributes[name] = value
+		} else {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+
+	config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configureResp, err := server.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{Config: &config})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range configureResp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	return server, schemaResp
+}
+
+func TestLagResource_UpgradeState(t *testing.T) {
+	for _, fixture := range []string{"lag_v0.tfstate", "lag_v1.tfstate"} {
+		t.Run(fixture, func(t *testing.T) {
+			server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+				"resource_upgrade_state_delay": tftypes.NewValue(tftypes.Number, 50),
+			})
+
+			state := readLagResourceStateFixture(t, fixture)
+
+			if state.SchemaVersion >= schemaResp.ResourceSchemas["testlagger_lag"].Version {
+				t.Fatalf("expected %s to be from a prior schema version, got version %d", fixture, state.SchemaVersion)
+			}
+
+			start := time.Now()
+
+			resp, err := server.UpgradeResourceState(context.Background(), &tfprotov6.UpgradeResourceStateRequest{
+				TypeName: "testlagger_lag",
+				Version:  state.SchemaVersion,
+				RawState: &tfprotov6.RawState{JSON: state.Attributes},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+				t.Errorf("expected the upgrade to take at least 50ms, took %s", elapsed)
+			}
+
+			for _, diagnostic := range resp.Diagnostics {
+				t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			upgraded, err := resp.UpgradedState.Unmarshal(schemaResp.ResourceSchemas["testlagger_lag"].ValueType())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var attributes map[string]tftypes.Value
+			if err := upgraded.As(&attributes); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, want := range map[string]int64{"create_delay": 1000, "read_delay": 2000, "update_delay": 3000, "delete_delay": 4000} {
+				var got big.Float
+				if err := attributes[name].As(&got); err != nil {
+					t.Fatal(err)
+				}
+
+				if value, _ := got.Int64(); value != want {
+					t.Errorf("expected %s to be %d, got %d", name, want, value)
+				}
+			}
+
+			for _, name := range []string{"id", "input", "output"} {
+				var got string
+				if err := attributes[name].As(&got); err != nil {
+					t.Fatal(err)
+				}
+
+				if got != "hello" {
+					t.Errorf("expected %s to be hello, got %q", name, got)
+				}
+			}
+
+			if !attributes["delay_distributions"].IsNull() {
+				t.Errorf("expected delay_distributions to be null, got %s", attributes["delay_distributions"])
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &LagResourceV2{}
+var _ resource.ResourceWithMoveState = &LagResourceV2{}
+
+// NewLagResourceV2 returns the lag resource under its second type name.
+// Terraform does not configure a resource before moving state to it, so
+// client returns the configured client, or nil if the provider has not been
+// configured yet.
+func NewLagResourceV2(client func() *TestLaggerClient) resource.Resource {
+	return &LagResourceV2{
+		providerClient: client,
+	}
+}
+
+// LagResourceV2 behaves as LagResource, and accepts the state of a
+// testlagger_lag at any of its schema versions through a moved block.
+type LagResourceV2 struct {
+	LagResource
+
+	providerClient func() *TestLaggerClient
+}
+
+func (r *LagResourceV2) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	// Behaves as testlagger_lag under its own name
+	r.LagResource.Metadata(ctx, req, resp)
+	resp.TypeName = req.ProviderTypeName + "_lag_v2"
+}
+
+func (r *LagResourceV2) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = lagResourceSchema()
+	resp.Schema.MarkdownDescription = "Echos the given input after a delay. Accepts the state of a `testlagger_lag` moved to it with a `moved` block."
+	resp.Schema.Version = 0
+}
+
+// UpgradeState returns no upgraders, as the resource has no prior schema
+// versions of its own.
+func (r *LagResourceV2) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return nil
+}
+
+func (r *LagResourceV2) MoveState(ctx context.Context) []resource.StateMover {
+	current := lagResourceSchema()
+
+	return []resource.StateMover{
+		r.lagStateMover(0, lagResourceSchemaV0(), func(ctx context.Context, state tfsdk.State) (LagResourceModel, diag.Diagnostics) {
+			var prior lagResourceModelV0
+			diags := state.Get(ctx, &prior)
+
+			return prior.upgrade(), diags
+		}),
+		r.lagStateMover(1, lagResourceSchemaV1(), func(ctx context.Context, state tfsdk.State) (LagResourceModel, diag.Diagnostics) {
+			var prior lagResourceModelV1
+			diags := state.Get(ctx, &prior)
+
+			return prior.upgrade(), diags
+		}),
+		r.lagStateMover(lagResourceSchemaVersion, &current, func(ctx context.Context, state tfsdk.State) (LagResourceModel, diag.Diagnostics) {
+			var prior LagResourceModel
+			diags := state.Get(ctx, &prior)
+
+			return prior, diags
+		}),
+	}
+}
+
+// lagStateMover returns a mover of testlagger_lag state at the given schema
+// version, which sleeps for the provider resource_move_state_delay and then
+// injects the provider resource_move_state_error.
+func (r *LagResourceV2) lagStateMover(version int64, sourceSchema *schema.Schema, convert func(context.Context, tfsdk.State) (LagResourceModel, diag.Diagnostics)) resource.StateMover {
+	return resource.StateMover{
+		SourceSchema: sourceSchema,
+		StateMover: func(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+			// Leave the state null for other movers to try
+			if req.SourceTypeName != "testlagger_lag" || req.SourceSchemaVersion != version || req.SourceState == nil {
+				return
+			}
+
+			moved, diags := convert(ctx, *req.SourceState)
+			resp.Diagnostics.Append(diags...)
+
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			// Prevent panic if the provider has not been configured.
+			if client := r.providerClient(); client != nil {
+				defer client.Concurrency.Start("resource_move_state")()
+
+				input := moved.Input.ValueString()
+				moveStateDelay := client.ResourceMoveStateDelay.Sample("resource/move_state/" + input)
+				moveStateCall := client.CountCall("resource/move_state")
+
+				// Client moves the source state
+				resp.Diagnostics.Append(sleep(ctx, client, lagPoint{Name: "Resource Lag Move State", Operation: "resource_move_state", ClientId: client.Id, Input: input}, moveStateDelay)...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				resp.Diagnostics.Append(injectFault(ctx, "Move State", client.ResourceMoveStateError, moveStateCall, "resource/move_state/"+input)...)
+
+				if resp.Diagnostics.HasError() {
+					return
+				}
+			}
+
+			resp.Diagnostics.Append(resp.TargetState.Set(ctx, moved)...)
+
+			if resp.TargetIdentity != nil {
+				resp.Diagnostics.Append(resp.TargetIdentity.Set(ctx, moved.identity())...)
+			}
+		},
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestLagResourceV2_Moved(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		PreCheck:                 func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testLagResourceConfig(100, 100, 100, 100, "one"),
+			},
+			{
+				Config: `
+provider "testlagger" {
+	resource_move_state_delay = 100
+}
+
+moved {
+	from = testlagger_lag.test
+	to   = testlagger_lag_v2.test
+}
+
+resource "testlagger_lag_v2" "test" {
+	create_delay = 100
+	read_delay = 100
+	update_delay = 100
+	delete_delay = 100
+	input = "one"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("testlagger_lag_v2.test", "create_delay", "100"),
+					resource.TestCheckResourceAttr("testlagger_lag_v2.test", "output", "one"),
+				),
+			},
+		},
+	})
+}
+
+func TestLagResourceV2_MoveState(t *testing.T) {
+	current, err := json.Marshal(map[string]any{
+		"id":           "hello",
+		"create_delay": 1000,
+		"read_delay":   2000,
+		"update_delay": 3000,
+		"delete_delay": 4000,
+		"input":        "hello",
+		"output":       "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]testLagResourceStateFixture{
+		"lag_v0.tfstate": readLagResourceStateFixture(t, "lag_v0.tfstate"),
+		"lag_v1.tfstate": readLagResourceStateFixture(t, "lag_v1.tfstate"),
+		"current":        {SchemaVersion: lagResourceSchemaVersion, Attributes: current},
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+				"resource_move_state_delay": tftypes.NewValue(tftypes.Number, 50),
+			})
+
+			start := time.Now()
+
+			resp, err := server.MoveResourceState(context.Background(), &tfprotov6.MoveResourceStateRequest{
+				SourceProviderAddress: "registry.opentofu.org/opentofu/testlagger",
+				SourceTypeName:        "testlagger_lag",
+				SourceSchemaVersion:   source.SchemaVersion,
+				SourceState:           &tfprotov6.RawState{JSON: source.Attributes},
+				TargetTypeName:        "testlagger_lag_v2",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+				t.Errorf("expected the move to take at least 50ms, took %s", elapsed)
+			}
+
+			for _, diagnostic := range resp.Diagnostics {
+				t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			moved, err := resp.TargetState.Unmarshal(schemaResp.ResourceSchemas["testlagger_lag_v2"].ValueType())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var attributes map[string]tftypes.Value
+			if err := moved.As(&attributes); err != nil {
+				t.Fatal(err)
+			}
+
+			var createDelay big.Float
+			if err := attributes["create_delay"].As(&createDelay); err != nil {
+				t.Fatal(err)
+			}
+
+			if value, _ := createDelay.Int64(); value != 1000 {
+				t.Errorf("expected create_delay to be 1000, got %d", value)
+			}
+
+			var output string
+			if err := attributes["output"].As(&output); err != nil {
+				t.Fatal(err)
+			}
+
+			if output != "hello" {
+				t.Errorf("expected output to be hello, got %q", output)
+			}
+		})
+	}
+}
+
+func TestLagResourceV2_MoveStateError(t *testing.T) {
+	faultType := faultObjectType.TerraformType(context.Background()).(tftypes.Object)
+
+	server, _ := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"resource_move_state_error": tftypes.NewValue(faultType, map[string]tftypes.Value{
+			"message":     tftypes.NewValue(tftypes.String, "boom"),
+			"probability": tftypes.NewValue(tftypes.Number, nil),
+			"on_call":     tftypes.NewValue(tftypes.Number, nil),
+			"seed":        tftypes.NewValue(tftypes.Number, nil),
+		}),
+	})
+
+	source := readLagResourceStateFixture(t, "lag_v1.tfstate")
+
+	resp, err := server.MoveResourceState(context.Background(), &tfprotov6.MoveResourceStateRequest{
+		SourceProviderAddress: "registry.opentofu.org/opentofu/testlagger",
+		SourceTypeName:        "testlagger_lag",
+		SourceSchemaVersion:   source.SchemaVersion,
+		SourceState:           &tfprotov6.RawState{JSON: source.Attributes},
+		TargetTypeName:        "testlagger_lag_v2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Summary != "Injected Move State Error" {
+		t.Fatalf("expected an injected move state error, got %+v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxPayloadSize is the largest payload_size in kilobytes, 16 MB. A payload is
+// generated whole, with about 20 values per kilobyte that are copied into the
+// plan and state, so it takes several times its size in memory.
+const maxPayloadSize = 16384
+
+var (
+	payloadDescription       = "Value of any type to echo as `payload_output`, such as an object, list or map. Cannot be combined with `payload_size`"
+	payloadSizeDescription   = fmt.Sprintf("Size in kilobytes of a nested object to generate as `payload_output`, seeded from the input, to benchmark large values in state and plans. At most %d, or 16 MB, as the payload is generated in memory and copied into plans and state. Cannot be combined with `payload`", maxPayloadSize)
+	payloadOutputDescription = "The `payload` echoed, or the object generated for `payload_size`"
+)
+
+// payloadItemStrings is the number of 64 character strings in the data of
+// each item of a generated payload, making each item about a kilobyte once
+// encoded as JSON.
+const payloadItemStrings = 12
+
+// payloadItemTags is the number of tags of each item of a generated payload.
+const payloadItemTags = 4
+
+// payloadItemObjectType is the type of each item of a generated payload.
+var payloadItemObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"index": types.Int64Type,
+		"name":  types.StringType,
+		"tags":  types.MapType{ElemType: types.StringType},
+		"data":  types.ListType{ElemType: types.StringType},
+	},
+}
+
+// payloadObjectType is the type of a generated payload.
+var payloadObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"seed":    types.StringType,
+		"size_kb": types.Int64Type,
+		"items":   types.ListType{ElemType: payloadItemObjectType},
+	},
+}
+
+// generatePayload returns a nested object of about sizeKB kilobytes, with an
+// item of about a kilobyte for each. The same seed and size always give the
+// same payload, so that it only changes when they do.
+func generatePayload(seed string, sizeKB int64) types.Dynamic {
+	items := make([]attr.Value, 0, sizeKB)
+
+	for i := int64(0); i < sizeKB; i++ {
+		tags := map[string]attr.Value{}
+		for j := 0; j < payloadItemTags; j++ {
+			tags[fmt.Sprintf("tag%d", j)] = types.StringValue(payloadString(seed, i, "tag", j)[:16])
+		}
+
+		data := make([]attr.Value, 0, payloadItemStrings)
+		for j := 0; j < payloadItemStrings; j++ {
+			data = append(data, types.StringValue(payloadString(seed, i, "data", j)))
+		}
+
+		items = append(items, types.ObjectValueMust(payloadItemObjectType.AttrTypes, map[string]attr.Value{
+			"index": types.Int64Value(i),
+			"name":  types.StringValue(fmt.Sprintf("%s-%d", seed, i)),
+			"tags":  types.MapValueMust(types.StringType, tags),
+			"data":  types.ListValueMust(types.StringType, data),
+		}))
+	}
+
+	return types.DynamicValue(types.ObjectValueMust(payloadObjectType.AttrTypes, map[string]attr.Value{
+		"seed":    types.StringValue(seed),
+		"size_kb": types.Int64Value(sizeKB),
+		"items":   types.ListValueMust(payloadItemObjectType, items),
+	}))
+}
+
+// payloadString returns the 64 character hex string at the given position
+// of a generated payload.
+func payloadString(seed string, item int64, field string, index int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%s/%d", seed, item, field, index)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// payloadOutput returns the payload_output of a payload and payload_size,
+// which is the payload echoed, the payload generated from the seed when
+// payload_size is set, or null when neither is.
+func payloadOutput(payload types.Dynamic, payloadSize types.Int64, seed string) types.Dynamic {
+	if !payloadSize.IsNull() {
+		return generatePayload(seed, payloadSize.ValueInt64())
+	}
+
+	if payload.IsNull() || payload.IsUnderlyingValueNull() {
+		return types.DynamicNull()
+	}
+
+	return payload
+}
+
+// payloadKey returns the key that a dynamic value is sampled and logged
+// under, which is the value itself for strings and a digest of it otherwise.
+func payloadKey(value types.Dynamic) string {
+	if value, ok := value.UnderlyingValue().(types.String); ok {
+		return value.ValueString()
+	}
+
+	sum := sha256.Sum256([]byte(value.String()))
+
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// validatePayload checks the payload and payload_size attributes of the
+// configuration.
+func validatePayload(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var payload types.Dynamic
+	var payloadSize types.Int64
+
+	diags := config.GetAttribute(ctx, path.Root("payload"), &payload)
+	diags.Append(config.GetAttribute(ctx, path.Root("payload_size"), &payloadSize)...)
+
+	if diags.HasError() || payloadSize.IsNull() || payloadSize.IsUnknown() {
+		return diags
+	}
+
+	if payloadSize.ValueInt64() < 0 || payloadSize.ValueInt64() > maxPayloadSize {
+		diags.AddAttributeError(
+			path.Root("payload_size"),
+			"Invalid Payload Size",
+			fmt.Sprintf("payload_size must be from 0 to %d, got %d", maxPayloadSize, payloadSize.ValueInt64()),
+		)
+	}
+
+	if !payload.IsNull() {
+		diags.AddAttributeError(
+			path.Root("payload_size"),
+			"Conflicting Payloads",
+			"payload and payload_size cannot both be set, as payload_output either echoes the payload or is generated.",
+		)
+	}
+
+	return diags
+}
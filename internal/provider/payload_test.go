@@ -0,0 +1,182 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestGeneratePayload(t *testing.T) {
+	payload := generatePayload("hello", 64)
+
+	if !payload.Equal(generatePayload("hello", 64)) {
+		t.Error("expected the same seed and size to generate the same payload")
+	}
+
+	if payload.Equal(generatePayload("world", 64)) {
+		t.Error("expected a different seed to generate a different payload")
+	}
+
+	value, err := payload.UnderlyingValue().ToTerraformValue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := tfprotov6.NewDynamicValue(tftypes.DynamicPseudoType, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each item is about a kilobyte
+	if size := len(encoded.MsgPack); size < 48*1024 || size > 80*1024 {
+		t.Errorf("expected a payload of about 64KB, got %d bytes", size)
+	}
+}
+
+func TestPayloadOutput(t *testing.T) {
+	payload := types.DynamicValue(types.ListValueMust(types.StringType, nil))
+
+	if got := payloadOutput(payload, types.Int64Null(), "hello"); !got.Equal(payload) {
+		t.Errorf("expected the payload to be echoed, got %s", got)
+	}
+
+	if got := payloadOutput(types.DynamicNull(), types.Int64Value(2), "hello"); !got.Equal(generatePayload("hello", 2)) {
+		t.Errorf("expected a generated payload, got %s", got)
+	}
+
+	if got := payloadOutput(types.DynamicNull(), types.Int64Null(), "hello"); !got.IsNull() {
+		t.Errorf("expected a null payload output, got %s", got)
+	}
+}
+
+func TestValidatePayload(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	resourceType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+	dataSourceType := schemaResp.DataSourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	testCases := map[string]struct {
+		payloadSize int64
+		want        string
+	}{
+		"largest":   {payloadSize: maxPayloadSize},
+		"too-large": {payloadSize: maxPayloadSize + 1, want: "Invalid Payload Size"},
+		"negative":  {payloadSize: -1, want: "Invalid Payload Size"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			for _, configType := range []tftypes.Object{resourceType, dataSourceType} {
+				attributes := map[string]tftypes.Value{}
+				for name, attributeType := range configType.AttributeTypes {
+					attributes[name] = tftypes.NewValue(attributeType, nil)
+				}
+
+				attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+				attributes["payload_size"] = tftypes.NewValue(tftypes.Number, testCase.payloadSize)
+
+				config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var diagnostics []*tfprotov6.Diagnostic
+
+				if configType.Equal(resourceType) {
+					resp, err := server.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{TypeName: "testlagger_lag", Config: &config})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					diagnostics = resp.Diagnostics
+				} else {
+					resp, err := server.ValidateDataResourceConfig(context.Background(), &tfprotov6.ValidateDataResourceConfigRequest{TypeName: "testlagger_lag", Config: &config})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					diagnostics = resp.Diagnostics
+				}
+
+				if testCase.want == "" && len(diagnostics) > 0 {
+					t.Errorf("unexpected diagnostic %s: %s", diagnostics[0].Summary, diagnostics[0].Detail)
+				}
+
+				if testCase.want != "" && (len(diagnostics) != 1 || diagnostics[0].Summary != testCase.want) {
+					t.Errorf("expected %q, got %v", testCase.want, diagnostics)
+				}
+			}
+		})
+	}
+}
+
+func TestLagResource_Payload(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, nil)
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	payloadType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"name": tftypes.String,
+		"tags": tftypes.List{ElementType: tftypes.String},
+	}}
+
+	payload := tftypes.NewValue(payloadType, map[string]tftypes.Value{
+		"name": tftypes.NewValue(tftypes.String, "hello"),
+		"tags": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "a"),
+		}),
+	})
+
+	created, errors := testCreateLagResource(t, server, stateType, map[string]tftypes.Value{
+		"input":   tftypes.NewValue(tftypes.String, "one"),
+		"payload": payload,
+	})
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	state, err := created.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attributes map[string]tftypes.Value
+	if err := state.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+
+	if !attributes["payload_output"].Equal(payload) {
+		t.Errorf("expected payload_output to echo %s, got %s", payload, attributes["payload_output"])
+	}
+}
+
+func TestLagResource_PayloadSize(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "testlagger_lag" "test" {
+					input        = "testvalue"
+					payload_size = 4
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("testlagger_lag.test", tfjsonpath.New("payload_output").AtMapKey("items"), knownvalue.ListSizeExact(4)),
+				},
+			},
+		},
+	})
+}
@@ -10,18 +10,26 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
-	"time"
+	"sync"
+	"sync/atomic"
 )
 
 // Ensure TestLaggerProvider satisfies various provider interfaces.
 var _ provider.Provider = &TestLaggerProvider{}
 var _ provider.ProviderWithFunctions = &TestLaggerProvider{}
+var _ provider.ProviderWithEphemeralResources = &TestLaggerProvider{}
+var _ provider.ProviderWithListResources = &TestLaggerProvider{}
+var _ provider.ProviderWithConfigValidators = &TestLaggerProvider{}
+var _ provider.ProviderWithValidateConfig = &TestLaggerProvider{}
 
 // TestLaggerProvider defines the provider implementation.
 type TestLaggerProvider struct {
@@ -29,25 +37,69 @@ type TestLaggerProvider struct {
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+
+	// client is the client created by the most recent call to Configure.
+	// Functions are not passed provider data, so they look it up here.
+	client atomic.Pointer[TestLaggerClient]
+
+	// startup holds the options that apply before the provider is
+	// configured.
+	startup StartupOptions
 }
 
 // TestLaggerProviderModel describes the provider data model.
 type TestLaggerProviderModel struct {
-	ClientInitializeDelay    types.Int64 `tfsdk:"client_initialize_delay"`
-	DatasourceConfigureDelay types.Int64 `tfsdk:"datasource_configure_delay"`
-	ResourceConfigureDelay   types.Int64 `tfsdk:"resource_configure_delay"`
-	ResourceImportStateDelay types.Int64 `tfsdk:"resource_import_state_delay"`
+	ValidateDelay             types.Int64   `tfsdk:"validate_delay"`
+	ClientInitializeDelay     types.Int64   `tfsdk:"client_initialize_delay"`
+	DatasourceConfigureDelay  types.Int64   `tfsdk:"datasource_configure_delay"`
+	ResourceConfigureDelay    types.Int64   `tfsdk:"resource_configure_delay"`
+	ResourceImportStateDelay  types.Int64   `tfsdk:"resource_import_state_delay"`
+	ResourcePlanDelay         types.Int64   `tfsdk:"resource_plan_delay"`
+	ResourceUpgradeStateDelay types.Int64   `tfsdk:"resource_upgrade_state_delay"`
+	ResourceMoveStateDelay    types.Int64   `tfsdk:"resource_move_state_delay"`
+	ResourceIdentityDelay     types.Int64   `tfsdk:"resource_identity_delay"`
+	EphemeralConfigureDelay   types.Int64   `tfsdk:"ephemeral_configure_delay"`
+	DelayDistributions        types.Map     `tfsdk:"delay_distributions"`
+	IgnoreCancellation        types.Bool    `tfsdk:"ignore_cancellation"`
+	JournalPath               types.String  `tfsdk:"journal_path"`
+	RemoteStorePath           types.String  `tfsdk:"remote_store_path"`
+	Endpoint                  types.String  `tfsdk:"endpoint"`
+	RequestsPerSecond         types.Float64 `tfsdk:"requests_per_second"`
+	Burst                     types.Int64   `tfsdk:"burst"`
+	MaxConcurrentRequests     types.Int64   `tfsdk:"max_concurrent_requests"`
+	ThrottleMode              types.String  `tfsdk:"throttle_mode"`
+	RetryBackoff              types.Int64   `tfsdk:"retry_backoff"`
+	MaxRetries                types.Int64   `tfsdk:"max_retries"`
+	ConsistencyPollInterval   types.Int64   `tfsdk:"consistency_poll_interval"`
+	ConsistencyTimeout        types.Int64   `tfsdk:"consistency_timeout"`
+	DeferAll                  types.Bool    `tfsdk:"defer_all"`
+	ResourceMoveStateError    types.Object  `tfsdk:"resource_move_state_error"`
+	ValidateError             types.Object  `tfsdk:"validate_error"`
+	ValidateWarning           types.String  `tfsdk:"validate_warning"`
 }
 
+// providerLagPoints are the lag points that can be given a delay
+// distribution on the provider.
+var providerLagPoints = []string{"validate", "client_initialize", "datasource_configure", "resource_configure", "resource_import_state", "resource_plan", "resource_upgrade_state", "resource_move_state", "resource_identity", "ephemeral_configure"}
+
 func (p *TestLaggerProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	// Metadata has no diagnostics to report cancellation with
+	_ = sleep(ctx, nil, lagPoint{Name: "Provider Metadata", Operation: "provider_metadata"}, p.startup.MetadataDelay)
+
 	resp.TypeName = "testlagger"
 	resp.Version = p.version
 }
 
 func (p *TestLaggerProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Diagnostics.Append(sleep(ctx, nil, lagPoint{Name: "Provider Schema", Operation: "provider_schema"}, p.startup.SchemaDelay)...)
+
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "A provider for testing the dependency graph and execution engine.",
 		Attributes: map[string]schema.Attribute{
+			"validate_delay": schema.Int64Attribute{
+				MarkdownDescription: validateDelayDescription,
+				Optional:            true,
+			},
 			"client_initialize_delay": schema.Int64Attribute{
 				MarkdownDescription: "Amount of time in milliseconds to delay before client is created",
 				Optional:            true,
@@ -64,15 +116,158 @@ func (p *TestLaggerProvider) Schema(ctx context.Context, req provider.SchemaRequ
 				MarkdownDescription: "Amount of time in milliseconds to delay before resource import state function returns",
 				Optional:            true,
 			},
+			"resource_plan_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before resource plan modification returns, for resources that do not set `plan_delay`",
+				Optional:            true,
+			},
+			"resource_upgrade_state_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before resource state upgrade returns, when a resource has state from a prior schema version",
+				Optional:            true,
+			},
+			"resource_move_state_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before resource move state returns, when a `moved` block moves a `testlagger_lag` to a `testlagger_lag_v2`",
+				Optional:            true,
+			},
+			"resource_identity_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before resource import state returns when a resource is imported by its identity rather than its ID, in addition to `resource_import_state_delay`",
+				Optional:            true,
+			},
+			"ephemeral_configure_delay": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds to delay before ephemeral resource configure function returns",
+				Optional:            true,
+			},
+			"delay_distributions": providerDelayDistributionsAttribute(providerLagPoints...),
+			"ignore_cancellation": schema.BoolAttribute{
+				MarkdownDescription: "Keep sleeping when Terraform asks the provider to stop, simulating a provider that does not respond to cancellation",
+				Optional:            true,
+			},
+			"journal_path": schema.StringAttribute{
+				MarkdownDescription: "Path of a file to append a JSON line to whenever a lag point starts or finishes sleeping. The file can be shared by several provider processes",
+				Optional:            true,
+			},
+			"remote_store_path": schema.StringAttribute{
+				MarkdownDescription: "Path of a directory to keep a JSON file for each `testlagger_lag` in, as a fake remote API. When set, resources are read back from the directory, so changing or deleting its files, or applying a `testlagger_drift`, shows up as drift. The directory can be shared by several provider processes",
+				Optional:            true,
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "Path of the Unix socket of a `terraform-provider-testlagger daemon`, which holds the call counts and remote objects shared by every provider process that sets the same endpoint, so that plan and apply or parallel runs see each other's calls and objects. Cannot be combined with `remote_store_path`",
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: "Maximum rate of the calls that resources, data sources, ephemeral resources and functions make to the simulated API, including resource plans and imports, shared by every provider process using the same `endpoint`. Calls are not rate limited when not set",
+				Optional:            true,
+			},
+			"burst": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of calls that can be made at once before `requests_per_second` applies. Defaults to %d", defaultThrottleBurst),
+				Optional:            true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of calls to the simulated API in flight at once in this provider process, after which calls queue. Not limited when not set",
+				Optional:            true,
+			},
+			"throttle_mode": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("`%s` to make calls over `requests_per_second` wait their turn, or `%s` to fail them with a retryable error that is retried with exponential back-off. Defaults to `%s`", ThrottleModeQueue, ThrottleModeRetry, ThrottleModeQueue),
+				Optional:            true,
+			},
+			"retry_backoff": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Amount of time in milliseconds to back off before the first retry of a throttled call in `%s` mode, doubling with each retry. Defaults to %d", ThrottleModeRetry, defaultThrottleRetryBackoff),
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of times a throttled call is retried in `%s` mode before it fails. Defaults to %d", ThrottleModeRetry, defaultThrottleMaxRetries),
+				Optional:            true,
+			},
+			"consistency_poll_interval": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds between the polls that a `testlagger_lag` with a `consistency_delay` makes after a create or update until reads are consistent. Creates and updates do not wait when not set",
+				Optional:            true,
+			},
+			"consistency_timeout": schema.Int64Attribute{
+				MarkdownDescription: "Amount of time in milliseconds after which a create or update stops polling and fails when reads are still not consistent. Polls until consistent when not set",
+				Optional:            true,
+			},
+			"defer_all": schema.BoolAttribute{
+				MarkdownDescription: "Defer every resource and data source of the provider to a later plan and apply, as if the provider configuration had unknown values. Ignored with a warning unless Terraform allows deferral",
+				Optional:            true,
+			},
+			"resource_move_state_error": providerFaultAttribute("resource move state"),
+			"validate_error":            providerFaultAttribute("validate"),
+			"validate_warning": schema.StringAttribute{
+				MarkdownDescription: validateWarningDescription,
+				Optional:            true,
+			},
 		},
 	}
 }
 
 type TestLaggerClient struct {
-	Id                       string
-	DatasourceConfigureDelay int64
-	ResourceConfigureDelay   int64
-	ResourceImportStateDelay int64
+	Id                        string
+	DatasourceConfigureDelay  DelaySpec
+	ResourceConfigureDelay    DelaySpec
+	ResourceImportStateDelay  DelaySpec
+	ResourcePlanDelay         DelaySpec
+	ResourceUpgradeStateDelay DelaySpec
+	ResourceMoveStateDelay    DelaySpec
+	ResourceMoveStateError    types.Object
+	ResourceIdentityDelay     DelaySpec
+	EphemeralConfigureDelay   DelaySpec
+	IgnoreCancellation        bool
+	Concurrency               *ConcurrencyTracker
+	Journal                   *Journal
+	RemoteStore               ObjectStore
+	Daemon                    *DaemonClient
+	Throttle                  *Throttle
+	ConsistencyPollInterval   int64
+	ConsistencyTimeout        int64
+	ConfigUnknown             bool
+
+	calls callCounter
+}
+
+// CountCall records a call to the given lag point and returns the number of
+// calls made to it so far, counting from 1. Calls are counted by the daemon
+// when there is one, across every process using it, falling back to this
+// client's own count if the daemon cannot be reached.
+func (c *TestLaggerClient) CountCall(lagPoint string) int64 {
+	if c.Daemon != nil {
+		if count, err := c.Daemon.CountCall(lagPoint); err == nil {
+			return count
+		}
+	}
+
+	return c.calls.Count(lagPoint)
+}
+
+// callCounter counts the calls made to each lag point. The zero value is
+// ready to use.
+type callCounter struct {
+	mutex sync.Mutex
+	calls map[string]int64
+}
+
+// Count records a call to the given lag point and returns the number of calls
+// made to it so far, counting from 1.
+func (c *callCounter) Count(lagPoint string) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.calls == nil {
+		c.calls = map[string]int64{}
+	}
+
+	c.calls[lagPoint]++
+
+	return c.calls[lagPoint]
+}
+
+func (p *TestLaggerProvider) ConfigValidators(ctx context.Context) []provider.ConfigValidator {
+	return []provider.ConfigValidator{
+		validationDelay{name: "Provider Validate", operation: "provider_validate", key: "provider/validate", lagPoints: providerLagPoints},
+	}
+}
+
+func (p *TestLaggerProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	// Inject configured warnings and errors once the delay has elapsed
+	resp.Diagnostics.Append(injectValidationDiagnostics(ctx, req.Config, "provider/validate", false)...)
 }
 
 func (p *TestLaggerProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
@@ -86,77 +281,250 @@ func (p *TestLaggerProvider) Configure(ctx context.Context, req provider.Configu
 
 	id := uuid.New().String()
 
-	var clientInitializeDelay int64
-	if !(data.ClientInitializeDelay.IsNull() || data.ClientInitializeDelay.IsUnknown()) {
-		clientInitializeDelay = data.ClientInitializeDelay.ValueInt64()
-	} else {
-		clientInitializeDelay = 0
-	}
+	specs, diags := delaySpecs(ctx, data.DelayDistributions, providerLagPoints...)
+	resp.Diagnostics.Append(diags...)
 
-	var datasourceConfigureDelay int64
-	if !(data.DatasourceConfigureDelay.IsNull() || data.DatasourceConfigureDelay.IsUnknown()) {
-		datasourceConfigureDelay = data.DatasourceConfigureDelay.ValueInt64()
-	} else {
-		datasourceConfigureDelay = 0
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	var resourceConfigureDelay int64
-	if !(data.ResourceConfigureDelay.IsNull() || data.ResourceConfigureDelay.IsUnknown()) {
-		resourceConfigureDelay = data.ResourceConfigureDelay.ValueInt64()
-	} else {
-		resourceConfigureDelay = 0
+	var journal *Journal
+
+	if !data.JournalPath.IsNull() && data.JournalPath.ValueString() != "" {
+		var err error
+
+		journal, err = OpenJournal(data.JournalPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("journal_path"),
+				"Unable to Open Journal",
+				fmt.Sprintf("Unable to open journal %q: %s", data.JournalPath.ValueString(), err),
+			)
+
+			return
+		}
 	}
 
-	var resourceImportStateDelay int64
-	if !(data.ResourceImportStateDelay.IsNull() || data.ResourceImportStateDelay.IsUnknown()) {
-		resourceImportStateDelay = data.ResourceImportStateDelay.ValueInt64()
-	} else {
-		resourceImportStateDelay = 0
+	var remoteStore ObjectStore
+	var daemon *DaemonClient
+
+	if !data.Endpoint.IsNull() && data.Endpoint.ValueString() != "" {
+		if !data.RemoteStorePath.IsNull() && data.RemoteStorePath.ValueString() != "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("endpoint"),
+				"Conflicting Remote Stores",
+				"endpoint and remote_store_path cannot both be set, as the daemon at endpoint holds the remote objects.",
+			)
+
+			return
+		}
+
+		daemon = NewDaemonClient(data.Endpoint.ValueString())
+
+		if _, err := daemon.Health(ctx); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("endpoint"),
+				"Unable to Reach Daemon",
+				fmt.Sprintf("Unable to reach the daemon at %q: %s. Start it with: terraform-provider-testlagger daemon -socket %s", data.Endpoint.ValueString(), err, data.Endpoint.ValueString()),
+			)
+
+			return
+		}
+
+		remoteStore = daemon
+	} else if !data.RemoteStorePath.IsNull() && data.RemoteStorePath.ValueString() != "" {
+		var err error
+
+		remoteStore, err = OpenRemoteStore(data.RemoteStorePath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("remote_store_path"),
+				"Unable to Open Remote Store",
+				fmt.Sprintf("Unable to open remote store %q: %s", data.RemoteStorePath.ValueString(), err),
+			)
+
+			return
+		}
 	}
 
-	if clientInitializeDelay > 0 {
-		startMessage := fmt.Sprintf("Provider Configure (%s): Start sleeping for %d seconds...", id, clientInitializeDelay)
-		tflog.Trace(ctx, startMessage)
+	throttle, diags := throttleOptions(data)
+	resp.Diagnostics.Append(diags...)
 
-		time.Sleep(time.Duration(clientInitializeDelay) * time.Millisecond)
+	if !data.ConsistencyPollInterval.IsNull() && data.ConsistencyPollInterval.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("consistency_poll_interval"), "Invalid Consistency Poll Interval", fmt.Sprintf("consistency_poll_interval must be at least 1, got %d", data.ConsistencyPollInterval.ValueInt64()))
+	}
 
-		finishMessage := fmt.Sprintf("Provider Configure (%s): Finished sleeping for %d seconds...", id, clientInitializeDelay)
-		tflog.Trace(ctx, finishMessage)
+	if data.ConsistencyTimeout.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("consistency_timeout"), "Invalid Consistency Timeout", fmt.Sprintf("consistency_timeout must not be negative, got %d", data.ConsistencyTimeout.ValueInt64()))
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	client := &TestLaggerClient{
-		Id:                       id,
-		DatasourceConfigureDelay: datasourceConfigureDelay,
-		ResourceConfigureDelay:   resourceConfigureDelay,
-		ResourceImportStateDelay: resourceImportStateDelay,
+		Id:                        id,
+		DatasourceConfigureDelay:  resolveDelay(specs, "datasource_configure", data.DatasourceConfigureDelay),
+		ResourceConfigureDelay:    resolveDelay(specs, "resource_configure", data.ResourceConfigureDelay),
+		ResourceImportStateDelay:  resolveDelay(specs, "resource_import_state", data.ResourceImportStateDelay),
+		ResourcePlanDelay:         resolveDelay(specs, "resource_plan", data.ResourcePlanDelay),
+		ResourceUpgradeStateDelay: resolveDelay(specs, "resource_upgrade_state", data.ResourceUpgradeStateDelay),
+		ResourceMoveStateDelay:    resolveDelay(specs, "resource_move_state", data.ResourceMoveStateDelay),
+		ResourceMoveStateError:    data.ResourceMoveStateError,
+		ResourceIdentityDelay:     resolveDelay(specs, "resource_identity", data.ResourceIdentityDelay),
+		EphemeralConfigureDelay:   resolveDelay(specs, "ephemeral_configure", data.EphemeralConfigureDelay),
+		IgnoreCancellation:        data.IgnoreCancellation.ValueBool(),
+		Concurrency:               NewConcurrencyTracker(),
+		Journal:                   journal,
+		RemoteStore:               remoteStore,
+		Daemon:                    daemon,
+		Throttle:                  NewThrottle(throttle),
+		ConsistencyPollInterval:   data.ConsistencyPollInterval.ValueInt64(),
+		ConsistencyTimeout:        data.ConsistencyTimeout.ValueInt64(),
+		ConfigUnknown:             !req.Config.Raw.IsFullyKnown(),
 	}
 
+	defer client.Concurrency.Start("provider_configure")()
+
+	clientInitializeDelay := resolveDelay(specs, "client_initialize", data.ClientInitializeDelay).Sample("provider/client_initialize")
+
+	resp.Diagnostics.Append(sleep(ctx, client, lagPoint{Name: "Provider Configure", Operation: "provider_configure", ClientId: id}, clientInitializeDelay)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.client.Store(client)
+
 	resp.DataSourceData = client
 	resp.ResourceData = client
+	resp.EphemeralResourceData = client
+	resp.ListResourceData = client
+
+	if !data.DeferAll.ValueBool() {
+		return
+	}
+
+	// Terraform defers every resource and data source of the provider
+	if !req.ClientCapabilities.DeferralAllowed {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("defer_all"),
+			"Deferral Not Allowed",
+			"defer_all is ignored, as Terraform does not allow deferred actions in this run.",
+		)
+
+		return
+	}
+
+	resp.Deferred = &provider.Deferred{Reason: provider.DeferredReasonProviderConfigUnknown}
+}
+
+// throttleOptions returns the throttle options of the provider
+// configuration, with defaults for those that are not set.
+func throttleOptions(data TestLaggerProviderModel) (ThrottleOptions, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	options := ThrottleOptions{
+		RequestsPerSecond:     data.RequestsPerSecond.ValueFloat64(),
+		Burst:                 defaultThrottleBurst,
+		MaxConcurrentRequests: data.MaxConcurrentRequests.ValueInt64(),
+		Mode:                  ThrottleModeQueue,
+		RetryBackoff:          defaultThrottleRetryBackoff,
+		MaxRetries:            defaultThrottleMaxRetries,
+	}
+
+	if !data.Burst.IsNull() {
+		options.Burst = data.Burst.ValueInt64()
+	}
+
+	if !data.ThrottleMode.IsNull() {
+		options.Mode = data.ThrottleMode.ValueString()
+	}
+
+	if !data.RetryBackoff.IsNull() {
+		options.RetryBackoff = data.RetryBackoff.ValueInt64()
+	}
+
+	if !data.MaxRetries.IsNull() {
+		options.MaxRetries = data.MaxRetries.ValueInt64()
+	}
+
+	if !data.RequestsPerSecond.IsNull() && options.RequestsPerSecond <= 0 {
+		diags.AddAttributeError(path.Root("requests_per_second"), "Invalid Requests Per Second", fmt.Sprintf("requests_per_second must be positive, got %g", options.RequestsPerSecond))
+	}
+
+	if options.Burst < 1 {
+		diags.AddAttributeError(path.Root("burst"), "Invalid Burst", fmt.Sprintf("burst must be at least 1, got %d", options.Burst))
+	}
+
+	if !data.MaxConcurrentRequests.IsNull() && options.MaxConcurrentRequests < 1 {
+		diags.AddAttributeError(path.Root("max_concurrent_requests"), "Invalid Max Concurrent Requests", fmt.Sprintf("max_concurrent_requests must be at least 1, got %d", options.MaxConcurrentRequests))
+	}
+
+	if options.Mode != ThrottleModeQueue && options.Mode != ThrottleModeRetry {
+		diags.AddAttributeError(path.Root("throttle_mode"), "Invalid Throttle Mode", fmt.Sprintf("Expected %s or %s, got %q", ThrottleModeQueue, ThrottleModeRetry, options.Mode))
+	}
+
+	if options.RetryBackoff < 0 || options.MaxRetries < 0 {
+		diags.AddError("Invalid Retries", fmt.Sprintf("retry_backoff and max_retries must not be negative, got %d and %d", options.RetryBackoff, options.MaxRetries))
+	}
+
+	return options, diags
 }
 
 func (p *TestLaggerProvider) Resources(_ context.Context) []func() resource.Resource {
-	return []func() resource.Resource{
+	resources := []func() resource.Resource{
 		NewLagResource,
+		func() resource.Resource {
+			return NewLagResourceV2(p.client.Load)
+		},
+		NewDriftResource,
 	}
+
+	return append(resources, NewSyntheticResources(p.startup.SyntheticResources, p.startup.SyntheticAttributes)...)
 }
 
 func (p *TestLaggerProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewLagDataSource,
+		NewConcurrencyDataSource,
+	}
+}
+
+func (p *TestLaggerProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewLagEphemeralResource,
+	}
+}
+
+func (p *TestLaggerProvider) ListResources(_ context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		NewLagListResource,
 	}
 }
 
 func (p *TestLaggerProvider) Functions(_ context.Context) []func() function.Function {
 	return []func() function.Function{
-		NewLagFunction,
+		func() function.Function {
+			return NewLagFunction(p.client.Load)
+		},
+		func() function.Function {
+			return NewLagDynamicFunction(p.client.Load)
+		},
 	}
 }
 
 func New(version string) func() provider.Provider {
+	return NewWithStartupOptions(version, StartupOptions{})
+}
+
+// NewWithStartupOptions is New with options that apply before the provider
+// is configured, normally read by StartupOptionsFromEnvironment.
+func NewWithStartupOptions(version string, startup StartupOptions) func() provider.Provider {
 	return func() provider.Provider {
 		return &TestLaggerProvider{
 			version: version,
+			startup: startup,
 		}
 	}
 }
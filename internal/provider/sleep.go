@@ -0,0 +1,125 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// lagPoint identifies a place in the provider life cycle where a delay is
+// added.
+type lagPoint struct {
+	// Name is used to prefix trace messages, for example "Resource Lag Create".
+	Name string
+
+	// Operation is the machine readable name of the lag point, for example
+	// "resource_create".
+	Operation string
+
+	ClientId   string
+	InstanceId string
+	Input      string
+}
+
+// String returns the name of the lag point followed by the ids of the client
+// and instance.
+func (p lagPoint) String() string {
+	if p.ClientId == "" || p.InstanceId == "" {
+		return fmt.Sprintf("%s (%s%s)", p.Name, p.ClientId, p.InstanceId)
+	}
+
+	return fmt.Sprintf("%s (%s/%s)", p.Name, p.ClientId, p.InstanceId)
+}
+
+// sleep waits for delay milliseconds at the given lag point, tracing and
+// journaling when the wait starts and finishes. The wait is interrupted when
+// ctx is cancelled, for example by Ctrl-C or StopProvider, in which case an
+// error diagnostic reporting how much of the delay elapsed is returned. The
+// client may be nil when the provider has not been configured; otherwise its
+// IgnoreCancellation setting simulates a provider that does not respond to
+// stop requests by always waiting out the full delay.
+func sleep(ctx context.Context, client *TestLaggerClient, point lagPoint, delay int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if delay <= 0 {
+		return diags
+	}
+
+	var journal *Journal
+	var ignoreCancellation bool
+
+	if client != nil {
+		journal = client.Journal
+		ignoreCancellation = client.IgnoreCancellation
+	}
+
+	record := func(event string, elapsed *int64) {
+		if journal == nil {
+			return
+		}
+
+		err := journal.Record(JournalEvent{
+			Event:      event,
+			Operation:  point.Operation,
+			ClientId:   point.ClientId,
+			InstanceId: point.InstanceId,
+			Input:      point.Input,
+			DelayMs:    delay,
+			ElapsedMs:  elapsed,
+		})
+
+		if err != nil {
+			diags.AddWarning(
+				"Unable to Write Journal",
+				fmt.Sprintf("%s could not record its %s event: %s", point, event, err),
+			)
+		}
+	}
+
+	startMessage := fmt.Sprintf("%s: Start sleeping for %d milliseconds...\n", point, delay)
+	tflog.Trace(ctx, startMessage)
+	record(JournalEventStart, nil)
+
+	start := time.Now()
+	duration := time.Duration(delay) * time.Millisecond
+
+	if ignoreCancellation {
+		time.Sleep(duration)
+	} else {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			elapsed := time.Since(start).Milliseconds()
+
+			cancelledMessage := fmt.Sprintf("%s: Cancelled sleeping after %d of %d milliseconds...\n", point, elapsed, delay)
+			tflog.Trace(ctx, cancelledMessage)
+			record(JournalEventCancelled, &elapsed)
+
+			diags.AddError(
+				"Lag Cancelled",
+				fmt.Sprintf("%s was cancelled after %dms of its %dms delay had elapsed: %s", point, elapsed, delay, ctx.Err()),
+			)
+
+			return diags
+		}
+	}
+
+	elapsed := time.Since(start).Milliseconds()
+
+	finishMessage := fmt.Sprintf("%s: Finished sleeping for %d milliseconds...\n", point, delay)
+	tflog.Trace(ctx, finishMessage)
+	record(JournalEventFinish, &elapsed)
+
+	return diags
+}
@@ -0,0 +1,120 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testLagPoint = lagPoint{
+	Name:       "Test Lag",
+	Operation:  "test",
+	ClientId:   "client",
+	InstanceId: "instance",
+	Input:      "input",
+}
+
+func TestSleep(t *testing.T) {
+	start := time.Now()
+
+	diags := sleep(context.Background(), nil, testLagPoint, 50)
+
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to sleep for at least 50ms, slept for %s", elapsed)
+	}
+}
+
+func TestSleep_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	diags := sleep(ctx, nil, testLagPoint, 10000)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected sleep to be cancelled, slept for %s", elapsed)
+	}
+
+	if !diags.HasError() {
+		t.Fatal("expected cancellation error, got none")
+	}
+
+	if summary := diags[0].Summary(); summary != "Lag Cancelled" {
+		t.Fatalf("expected Lag Cancelled diagnostic, got %q", summary)
+	}
+
+	if detail := diags[0].Detail(); !strings.Contains(detail, "of its 10000ms delay had elapsed") {
+		t.Fatalf("expected detail to report elapsed delay, got %q", detail)
+	}
+}
+
+func TestSleep_IgnoreCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	diags := sleep(ctx, &TestLaggerClient{IgnoreCancellation: true}, testLagPoint, 50)
+
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to sleep for at least 50ms, slept for %s", elapsed)
+	}
+}
+
+func TestSleep_Journal(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := &TestLaggerClient{Journal: journal}
+
+	if diags := sleep(context.Background(), client, testLagPoint, 10); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if diags := sleep(ctx, client, testLagPoint, 10000); !diags.HasError() {
+		t.Fatal("expected cancellation error, got none")
+	}
+
+	events := readJournal(t, journal.file.Name())
+
+	want := []string{JournalEventStart, JournalEventFinish, JournalEventStart, JournalEventCancelled}
+
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+
+	for i, event := range events {
+		if event.Event != want[i] {
+			t.Fatalf("expected event %d to be %q, got %q", i, want[i], event.Event)
+		}
+
+		if event.Operation != "test" || event.ClientId != "client" || event.InstanceId != "instance" || event.Input != "input" {
+			t.Fatalf("unexpected lag point in event %d: %+v", i, event)
+		}
+	}
+
+	if events[1].DelayMs != 10 || events[1].ElapsedMs == nil || *events[1].ElapsedMs < 10 {
+		t.Fatalf("expected finish event to record the delay and elapsed time, got %+v", events[1])
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Environment variables read by StartupOptionsFromEnvironment.
+const (
+	SchemaDelayEnvironmentVariable         = "TESTLAGGER_SCHEMA_DELAY"
+	MetadataDelayEnvironmentVariable       = "TESTLAGGER_METADATA_DELAY"
+	SyntheticResourcesEnvironmentVariable  = "TESTLAGGER_SYNTHETIC_RESOURCES"
+	SyntheticAttributesEnvironmentVariable = "TESTLAGGER_SYNTHETIC_ATTRIBUTES"
+)
+
+// defaultSyntheticAttributes is the number of attributes of each synthetic
+// resource type when SyntheticAttributesEnvironmentVariable is not set.
+const defaultSyntheticAttributes = 100
+
+// StartupOptions change the provider before it is configured, so they are
+// read from the environment of the provider process rather than from the
+// provider configuration.
+type StartupOptions struct {
+	// SchemaDelay and MetadataDelay are the number of milliseconds to delay
+	// the provider Schema and Metadata responses by.
+	SchemaDelay   int64
+	MetadataDelay int64
+
+	// SyntheticResources is the number of testlagger_synthetic_<n> resource
+	// types to register, each with SyntheticAttributes optional attributes.
+	SyntheticResources  int
+	SyntheticAttributes int
+}
+
+// StartupOptionsFromEnvironment reads the startup options using getenv,
+// which is normally os.Getenv.
+func StartupOptionsFromEnvironment(getenv func(string) string) (StartupOptions, error) {
+	options := StartupOptions{
+		SyntheticAttributes: defaultSyntheticAttributes,
+	}
+
+	var errs []error
+
+	parse := func(name string, value *int64) {
+		text := getenv(name)
+		if text == "" {
+			return
+		}
+
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || number < 0 {
+			errs = append(errs, fmt.Errorf("%s must be a non-negative integer, got %q", name, text))
+			return
+		}
+
+		*value = number
+	}
+
+	syntheticResources := int64(options.SyntheticResources)
+	syntheticAttributes := int64(options.SyntheticAttributes)
+
+	parse(SchemaDelayEnvironmentVariable, &options.SchemaDelay)
+	parse(MetadataDelayEnvironmentVariable, &options.MetadataDelay)
+	parse(SyntheticResourcesEnvironmentVariable, &syntheticResources)
+	parse(SyntheticAttributesEnvironmentVariable, &syntheticAttributes)
+
+	options.SyntheticResources = int(syntheticResources)
+	options.SyntheticAttributes = int(syntheticAttributes)
+
+	return options, errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestStartupOptionsFromEnvironment(t *testing.T) {
+	environment := map[string]string{
+		SchemaDelayEnvironmentVariable:        "200",
+		SyntheticResourcesEnvironmentVariable: "10",
+	}
+
+	options, err := StartupOptionsFromEnvironment(func(name string) string { return environment[name] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := StartupOptions{SchemaDelay: 200, SyntheticResources: 10, SyntheticAttributes: defaultSyntheticAttributes}
+	if options != want {
+		t.Fatalf("expected %+v, got %+v", want, options)
+	}
+}
+
+func TestStartupOptionsFromEnvironment_Invalid(t *testing.T) {
+	environment := map[string]string{
+		MetadataDelayEnvironmentVariable:       "soon",
+		SyntheticAttributesEnvironmentVariable: "-1",
+	}
+
+	_, err := StartupOptionsFromEnvironment(func(name string) string { return environment[name] })
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, name := range []string{MetadataDelayEnvironmentVariable, SyntheticAttributesEnvironmentVariable} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s in %s", name, err)
+		}
+	}
+}
+
+func TestStartupOptions_Schema(t *testing.T) {
+	server, err := providerserver.NewProtocol6WithError(NewWithStartupOptions("test", StartupOptions{
+		SchemaDelay:         50,
+		SyntheticResources:  3,
+		SyntheticAttributes: 20,
+	})())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the schema to take at least 50ms, took %s", elapsed)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	if len(resp.ResourceSchemas) != 6 {
+		t.Errorf("expected the two lag resources, the drift resource and 3 synthetic resources, got %d resource schemas", len(resp.ResourceSchemas))
+	}
+
+	synthetic, ok := resp.ResourceSchemas["testlagger_synthetic_3"]
+	if !ok {
+		t.Fatal("expected testlagger_synthetic_3")
+	}
+
+	if got := len(synthetic.Block.Attributes); got != 21 {
+		t.Errorf("expected an id and 20 attributes, got %d attributes", got)
+	}
+}
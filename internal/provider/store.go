@@ -0,0 +1,116 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// RemoteObject is the remote copy of a lag resource, as held by a
+// RemoteStore.
+type RemoteObject struct {
+	Id        string  `json:"id"`
+	Namespace *string `json:"namespace,omitempty"`
+	Input     string  `json:"input"`
+	Output    string  `json:"output"`
+
+	// Generation is unique to each create, so that a resource replaced with
+	// create_before_destroy does not delete the object of its replacement,
+	// which has the same id.
+	Generation string `json:"generation,omitempty"`
+}
+
+// RemoteStore is a fake remote API holding a JSON file for each object in a
+// directory, named after the escaped object id. Objects are written to a
+// temporary file that is then renamed over the object, so that several
+// provider processes can share one store, and the files can be edited or
+// deleted out-of-band to simulate drift.
+type RemoteStore struct {
+	dir string
+}
+
+// OpenRemoteStore opens the remote store in dir, creating the directory if
+// necessary.
+func OpenRemoteStore(dir string) (*RemoteStore, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(absDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &RemoteStore{dir: absDir}, nil
+}
+
+// path returns the path of the file holding the object with the given id.
+func (s *RemoteStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// Get returns the object with the given id, or nil if there is none.
+func (s *RemoteStore) Get(id string) (*RemoteObject, error) {
+	content, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var object RemoteObject
+	if err := json.Unmarshal(content, &object); err != nil {
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// Put creates or replaces the object.
+func (s *RemoteStore) Put(object RemoteObject) error {
+	content, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(object.Id))
+	}
+
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// Delete deletes the object with the given id, if there is one.
+func (s *RemoteStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,358 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRemoteStore(t *testing.T) {
+	store, err := OpenRemoteStore(filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := "team"
+
+	for _, object := range []RemoteObject{
+		{Id: "one", Namespace: &namespace, Input: "one", Output: "one"},
+		{Id: "a/b", Input: "a/b", Output: "a/b"},
+	} {
+		if err := store.Put(object); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := store.Get(object.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got == nil || got.Id != object.Id || got.Input != object.Input || got.Output != object.Output {
+			t.Errorf("expected %+v, got %+v", object, got)
+		}
+	}
+
+	if err := store.Delete("one"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.Get("one"); err != nil || got != nil {
+		t.Errorf("expected a deleted object to be missing, got %+v, %v", got, err)
+	}
+
+	if err := store.Delete("one"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %s", err)
+	}
+}
+
+func TestLagResource_ReadRemoteStore(t *testing.T) {
+	dir := t.TempDir()
+
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"remote_store_path": tftypes.NewValue(tftypes.String, dir),
+	})
+
+	store, err := OpenRemoteStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range stateType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	for _, name := range []string{"id", "input", "output"} {
+		attributes[name] = tftypes.NewValue(tftypes.String, "one")
+	}
+
+	state, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identitySchemas, err := server.GetResourceIdentitySchemas(context.Background(), &tfprotov6.GetResourceIdentitySchemasRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identityType := identitySchemas.IdentitySchemas["testlagger_lag"].ValueType()
+
+	identity, err := tfprotov6.NewDynamicValue(identityType, tftypes.NewValue(identityType, map[string]tftypes.Value{
+		"namespace": tftypes.NewValue(tftypes.String, nil),
+		"input":     tftypes.NewValue(tftypes.String, "one"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func() tftypes.Value {
+		resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+			TypeName:        "testlagger_lag",
+			CurrentState:    &state,
+			CurrentIdentity: &tfprotov6.ResourceIdentityData{IdentityData: &identity},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, diagnostic := range resp.Diagnostics {
+			t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+
+		newState, err := resp.NewState.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return newState
+	}
+
+	if err := store.Put(RemoteObject{Id: "one", Input: "drifted", Output: "drifted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var newAttributes map[string]tftypes.Value
+	if err := read().As(&newAttributes); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"id": "one", "input": "drifted", "output": "drifted"} {
+		var got string
+		if err := newAttributes[name].As(&got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("expected %s to be %q, got %q", name, want, got)
+		}
+	}
+
+	if err := store.Delete("one"); err != nil {
+		t.Fatal(err)
+	}
+
+	if newState := read(); !newState.IsNull() {
+		t.Errorf("expected a deleted remote object to remove the resource, got %s", newState)
+	}
+}
+
+func TestLagResource_ReadRemoteStoreNullIdentity(t *testing.T) {
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"remote_store_path": tftypes.NewValue(tftypes.String, t.TempDir()),
+	})
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range stateType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	for _, name := range []string{"id", "input", "output"} {
+		attributes[name] = tftypes.NewValue(tftypes.String, "one")
+	}
+
+	state, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// State written before identity existed has none, and the remote object
+	// is missing
+	resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     "testlagger_lag",
+		CurrentState: &state,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	newState, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !newState.IsNull() {
+		t.Errorf("expected a missing remote object to remove the resource, got %s", newState)
+	}
+}
+
+func TestLagResource_DeleteReplacedRemoteObject(t *testing.T) {
+	dir := t.TempDir()
+
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"remote_store_path": tftypes.NewValue(tftypes.String, dir),
+	})
+
+	store, err := OpenRemoteStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+	values := map[string]tftypes.Value{"input": tftypes.NewValue(tftypes.String, "one")}
+
+	destroy := func(created *tfprotov6.ApplyResourceChangeResponse) {
+		planned, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+			TypeName:       "testlagger_lag",
+			PriorState:     created.NewState,
+			PlannedState:   &planned,
+			Config:         &planned,
+			PlannedPrivate: created.Private,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, diagnostic := range resp.Diagnostics {
+			t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+	}
+
+	// With create_before_destroy, the replacement is created first
+	original, errors := testCreateLagResource(t, server, stateType, values)
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	replacement, errors := testCreateLagResource(t, server, stateType, values)
+	if len(errors) > 0 {
+		t.Fatal(errors)
+	}
+
+	destroy(original)
+
+	if object, err := store.Get("one"); err != nil || object == nil {
+		t.Fatalf("expected destroying the original to keep the object of its replacement, got %v, %v", object, err)
+	}
+
+	destroy(replacement)
+
+	if object, err := store.Get("one"); err != nil || object != nil {
+		t.Fatalf("expected destroying the replacement to delete its object, got %v, %v", object, err)
+	}
+}
+
+func TestLagResource_ImportRemoteStore(t *testing.T) {
+	dir := t.TempDir()
+
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"remote_store_path": tftypes.NewValue(tftypes.String, dir),
+	})
+
+	stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	imported, err := server.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{
+		TypeName: "testlagger_lag",
+		ID:       "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range imported.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	// The read following the import finds the imported object
+	resp, err := server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:        "testlagger_lag",
+		CurrentState:    imported.ImportedResources[0].State,
+		CurrentIdentity: imported.ImportedResources[0].Identity,
+		Private:         imported.ImportedResources[0].Private,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diagnostic := range resp.Diagnostics {
+		t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	newState, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newState.IsNull() {
+		t.Error("expected the imported resource to be kept by the read following the import")
+	}
+}
+
+func TestLagListResource_RemoteStore(t *testing.T) {
+	dir := t.TempDir()
+
+	server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+		"remote_store_path": tftypes.NewValue(tftypes.String, dir),
+	})
+
+	store, err := OpenRemoteStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An object that has drifted is listed as it is
+	if err := store.Put(RemoteObject{Id: "bulk-1", Input: "bulk-1", Output: "drifted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	configType := schemaResp.ListResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range configType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+
+	attributes["object_count"] = tftypes.NewValue(tftypes.Number, 2)
+	attributes["name_pattern"] = tftypes.NewValue(tftypes.String, "bulk-%d")
+
+	config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := server.(tfprotov6.ListResourceServer).ListResource(context.Background(), &tfprotov6.ListResourceRequest{
+		TypeName: "testlagger_lag",
+		Config:   &config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for result := range stream.Results {
+		for _, diagnostic := range result.Diagnostics {
+			t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+		}
+	}
+
+	for id, want := range map[string]string{"bulk-1": "drifted", "bulk-2": "bulk-2"} {
+		object, err := store.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if object == nil || object.Output != want {
+			t.Errorf("expected the listed object %s with output %q, got %+v", id, want, object)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &SyntheticResource{}
+
+// NewSyntheticResources returns count synthetic resource types named
+// testlagger_synthetic_1, testlagger_synthetic_2... each with the given
+// number of attributes.
+func NewSyntheticResources(count int, attributes int) []func() resource.Resource {
+	resources := make([]func() resource.Resource, 0, count)
+
+	for number := 1; number <= count; number++ {
+		resources = append(resources, func() resource.Resource {
+			return &SyntheticResource{Number: number, Attributes: attributes}
+		})
+	}
+
+	return resources
+}
+
+// SyntheticResource is a resource type that exists to inflate the provider
+// schema. It stores its configuration without any delays.
+type SyntheticResource struct {
+	Number     int
+	Attributes int
+}
+
+func (r *SyntheticResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = fmt.Sprintf("%s_synthetic_%d", req.ProviderTypeName, r.Number)
+}
+
+func (r *SyntheticResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "Unique identifier",
+			Computed:            true,
+		},
+	}
+
+	for number := 1; number <= r.Attributes; number++ {
+		attributes[fmt.Sprintf("attribute_%d", number)] = schema.StringAttribute{
+			MarkdownDescription: fmt.Sprintf("Synthetic attribute %d of %d", number, r.Attributes),
+			Optional:            true,
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Stores its configuration, registered to measure the cost of large provider schemas.",
+		Attributes:          attributes,
+	}
+}
+
+func (r *SyntheticResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	resp.State.Raw = req.Plan.Raw.Copy()
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), uuid.New().String())...)
+}
+
+func (r *SyntheticResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The state is kept as it is
+}
+
+func (r *SyntheticResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var id types.String
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.State.Raw = req.Plan.Raw.Copy()
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+func (r *SyntheticResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Nothing to delete
+}
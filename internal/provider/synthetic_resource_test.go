@@ -0,0 +1,50 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestSyntheticResource(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck: func() { testPreCheck(t) },
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"testlagger": providerserver.NewProtocol6WithError(NewWithStartupOptions("test", StartupOptions{SyntheticResources: 2, SyntheticAttributes: 5})()),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "testlagger_synthetic_2" "test" {
+	attribute_1 = "one"
+	attribute_5 = "five"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("testlagger_synthetic_2.test", "id"),
+					resource.TestCheckResourceAttr("testlagger_synthetic_2.test", "attribute_1", "one"),
+					resource.TestCheckResourceAttr("testlagger_synthetic_2.test", "attribute_5", "five"),
+				),
+			},
+			{
+				Config: `
+resource "testlagger_synthetic_2" "test" {
+	attribute_1 = "uno"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("testlagger_synthetic_2.test", "id"),
+					resource.TestCheckResourceAttr("testlagger_synthetic_2.test", "attribute_1", "uno"),
+					resource.TestCheckNoResourceAttr("testlagger_synthetic_2.test", "attribute_5"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Throttle modes.
+const (
+	// ThrottleModeQueue makes calls over the rate limit wait their turn.
+	ThrottleModeQueue = "queue"
+
+	// ThrottleModeRetry fails calls over the rate limit with a retryable
+	// error, which the provider retries with exponential back-off.
+	ThrottleModeRetry = "retry"
+)
+
+const (
+	// defaultThrottleBurst is the burst when burst is not set.
+	defaultThrottleBurst = 1
+
+	// defaultThrottleRetryBackoff is the back-off in milliseconds before the
+	// first retry when retry_backoff is not set.
+	defaultThrottleRetryBackoff = 100
+
+	// defaultThrottleMaxRetries is the number of retries before a call
+	// fails when max_retries is not set.
+	defaultThrottleMaxRetries = 10
+
+	// maxThrottleRetryBackoff caps the back-off in milliseconds as it
+	// doubles.
+	maxThrottleRetryBackoff = 60000
+)
+
+// ThrottleOptions configure a Throttle.
+type ThrottleOptions struct {
+	// RequestsPerSecond is the rate at which calls are allowed, or 0 for no
+	// limit, and Burst the number of calls allowed at once before the rate
+	// applies.
+	RequestsPerSecond float64
+	Burst             int64
+
+	// MaxConcurrentRequests is the number of calls allowed in flight at
+	// once, or 0 for no limit.
+	MaxConcurrentRequests int64
+
+	// Mode is ThrottleModeQueue or ThrottleModeRetry. In retry mode, calls
+	// over the rate are retried up to MaxRetries times, after RetryBackoff
+	// milliseconds doubling with each retry.
+	Mode         string
+	RetryBackoff int64
+	MaxRetries   int64
+}
+
+// Throttle limits the rate and concurrency of the calls that resources, data
+// sources and functions make to the simulated API.
+type Throttle struct {
+	options ThrottleOptions
+
+	// slots holds a value for each call in flight, or is nil when the
+	// number of calls in flight is not limited.
+	slots chan struct{}
+
+	bucket TokenBucket
+}
+
+// NewThrottle returns a throttle with the given options.
+func NewThrottle(options ThrottleOptions) *Throttle {
+	throttle := &Throttle{options: options}
+
+	if options.MaxConcurrentRequests > 0 {
+		throttle.slots = make(chan struct{}, options.MaxConcurrentRequests)
+	}
+
+	return throttle
+}
+
+// backoff returns the back-off in milliseconds before the given retry,
+// counting from 0.
+func (t *Throttle) backoff(retry int64) int64 {
+	backoff := t.options.RetryBackoff
+
+	for i := int64(0); i < retry && backoff < maxThrottleRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxThrottleRetryBackoff)
+}
+
+// TokenBucket is a token bucket rate limiter. The zero value is a full
+// bucket.
+type TokenBucket struct {
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Reserve takes a token from a bucket refilled at rate tokens per second up
+// to burst, and returns how long the caller must wait for the token. When
+// queue is false and no token is available, it takes nothing and returns
+// false along with how long it will be until there is one.
+func (b *TokenBucket) Reserve(now time.Time, rate float64, burst int64, queue bool) (time.Duration, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else if now.After(b.last) {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	// Queued calls take tokens in advance, leaving the bucket in debt
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+
+	if !queue {
+		return wait, false
+	}
+
+	b.tokens--
+
+	return wait, true
+}
+
+// reserve takes a token from the daemon's bucket when there is a daemon,
+// falling back to this client's own bucket if it cannot be reached.
+func (c *TestLaggerClient) reserve(queue bool) (time.Duration, bool) {
+	options := c.Throttle.options
+
+	if c.Daemon != nil {
+		if wait, ok, err := c.Daemon.Reserve(options.RequestsPerSecond, options.Burst, queue); err == nil {
+			return wait, ok
+		}
+	}
+
+	return c.Throttle.bucket.Reserve(time.Now(), options.RequestsPerSecond, options.Burst, queue)
+}
+
+// throttleCall waits until the client may make the call at the given lag
+// point, and returns the number of times the call was retried after being
+// throttled along with a function to call once the call is finished. Time
+// spent waiting for the rate limit or backing off is slept at lag points
+// named after the call, so it shows up in traces and the journal. The client
+// may be nil when the provider has not been configured.
+func throttleCall(ctx context.Context, client *TestLaggerClient, point lagPoint) (int64, func(), diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var retries int64
+
+	done := func() {}
+
+	if client == nil || client.Throttle == nil {
+		return retries, done, diags
+	}
+
+	throttle := client.Throttle
+
+	if throttle.slots != nil {
+		select {
+		case throttle.slots <- struct{}{}:
+			done = func() { <-throttle.slots }
+		case <-ctx.Done():
+			diags.AddError(
+				"Lag Cancelled",
+				fmt.Sprintf("%s was cancelled while waiting for one of %d concurrent requests: %s", point, cap(throttle.slots), ctx.Err()),
+			)
+
+			return retries, done, diags
+		}
+	}
+
+	if throttle.options.RequestsPerSecond <= 0 {
+		return retries, done, diags
+	}
+
+	waitPoint := point
+	waitPoint.Name += " Throttled"
+	waitPoint.Operation = "throttle_wait"
+
+	retryPoint := point
+	retryPoint.Name += " Retry"
+	retryPoint.Operation = "throttle_retry"
+
+	for {
+		wait, ok := client.reserve(throttle.options.Mode != ThrottleModeRetry)
+
+		if ok {
+			// Client waits for its turn at the API
+			diags.Append(sleep(ctx, client, waitPoint, wait.Milliseconds())...)
+			break
+		}
+
+		if retries >= throttle.options.MaxRetries {
+			diags.AddError(
+				"Rate Limit Exceeded",
+				fmt.Sprintf("%s was still throttled after %d retries, as calls exceeded %g requests per second", point, retries, throttle.options.RequestsPerSecond),
+			)
+
+			break
+		}
+
+		// Client backs off after a 429 response
+		diags.Append(sleep(ctx, client, retryPoint, throttle.backoff(retries))...)
+		retries++
+
+		if diags.HasError() {
+			break
+		}
+	}
+
+	if diags.HasError() {
+		done()
+
+		return retries, func() {}, diags
+	}
+
+	return retries, done, diags
+}
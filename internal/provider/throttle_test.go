@@ -0,0 +1,232 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	var bucket TokenBucket
+
+	start := time.Now()
+
+	for i, expected := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		wait, ok := bucket.Reserve(start, 10, 2, true)
+
+		if !ok || wait != expected {
+			t.Errorf("expected call %d to wait %s, got %s, %t", i+1, expected, wait, ok)
+		}
+	}
+
+	// Without queueing, nothing is taken from the bucket in debt
+	for range 2 {
+		if wait, ok := bucket.Reserve(start, 10, 2, false); ok || wait != 300*time.Millisecond {
+			t.Errorf("expected a retryable call to be refused for 300ms, got %s, %t", wait, ok)
+		}
+	}
+
+	// The bucket refills up to the burst
+	later := start.Add(time.Minute)
+
+	for i, expected := range []bool{true, true, false} {
+		if _, ok := bucket.Reserve(later, 10, 2, false); ok != expected {
+			t.Errorf("expected call %d after refilling to be allowed: %t, got %t", i+1, expected, ok)
+		}
+	}
+}
+
+func TestThrottle_Backoff(t *testing.T) {
+	throttle := NewThrottle(ThrottleOptions{RetryBackoff: 100})
+
+	for retry, expected := range []int64{100, 200, 400, 800} {
+		if backoff := throttle.backoff(int64(retry)); backoff != expected {
+			t.Errorf("expected retry %d to back off %dms, got %dms", retry, expected, backoff)
+		}
+	}
+
+	if backoff := throttle.backoff(100); backoff != maxThrottleRetryBackoff {
+		t.Errorf("expected the back-off to be capped at %dms, got %dms", maxThrottleRetryBackoff, backoff)
+	}
+}
+
+func TestThrottleCall_Queue(t *testing.T) {
+	client := &TestLaggerClient{Throttle: NewThrottle(ThrottleOptions{RequestsPerSecond: 20, Burst: 1, Mode: ThrottleModeQueue})}
+
+	start := time.Now()
+
+	for range 3 {
+		retries, done, diags := throttleCall(context.Background(), client, testLagPoint)
+		done()
+
+		if diags.HasError() || retries != 0 {
+			t.Fatalf("expected queued calls to succeed without retries, got %d retries and %v", retries, diags)
+		}
+	}
+
+	// The second and third calls wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected three calls at 20 per second to take at least 100ms, took %s", elapsed)
+	}
+}
+
+func TestThrottleCall_Retry(t *testing.T) {
+	client := &TestLaggerClient{Throttle: NewThrottle(ThrottleOptions{RequestsPerSecond: 20, Burst: 1, Mode: ThrottleModeRetry, RetryBackoff: 10, MaxRetries: 10})}
+
+	for i, retried := range []bool{false, true} {
+		retries, done, diags := throttleCall(context.Background(), client, testLagPoint)
+		done()
+
+		if diags.HasError() || (retries > 0) != retried {
+			t.Errorf("expected call %d to be retried: %t, got %d retries and %v", i+1, retried, retries, diags)
+		}
+	}
+
+	client.Throttle.options.MaxRetries = 1
+	client.Throttle.options.RequestsPerSecond = 0.1
+
+	_, _, _ = throttleCall(context.Background(), client, testLagPoint)
+	retries, _, diags := throttleCall(context.Background(), client, testLagPoint)
+
+	if !diags.HasError() || !strings.Contains(diags.Errors()[0].Detail(), "still throttled after 1 retries") || retries != 1 {
+		t.Errorf("expected the call to fail after one retry, got %d retries and %v", retries, diags)
+	}
+}
+
+func TestThrottleCall_MaxConcurrentRequests(t *testing.T) {
+	client := &TestLaggerClient{Throttle: NewThrottle(ThrottleOptions{MaxConcurrentRequests: 1})}
+
+	_, done, diags := throttleCall(context.Background(), client, testLagPoint)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	started := make(chan struct{})
+
+	go func() {
+		_, secondDone, _ := throttleCall(context.Background(), client, testLagPoint)
+		secondDone()
+		close(started)
+	}()
+
+	select {
+	case <-started:
+		t.Fatal("expected the second call to queue while the first is in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second call to start once the first finished")
+	}
+
+	// Queued calls are interrupted by cancellation
+	_, done, _ = throttleCall(context.Background(), client, testLagPoint)
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, _, diags := throttleCall(ctx, client, testLagPoint); !diags.HasError() || diags.Errors()[0].Summary() != "Lag Cancelled" {
+		t.Errorf("expected the queued call to be cancelled, got %v", diags)
+	}
+}
+
+func TestProvider_ThrottleAllCalls(t *testing.T) {
+	// Each call is made twice, and the second exceeds the rate limit
+	calls := map[string]func(server tfprotov6.ProviderServer, schemaResp *tfprotov6.GetProviderSchemaResponse) []*tfprotov6.Diagnostic{
+		"import": func(server tfprotov6.ProviderServer, _ *tfprotov6.GetProviderSchemaResponse) []*tfprotov6.Diagnostic {
+			resp, err := server.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{TypeName: "testlagger_lag", ID: "one"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return resp.Diagnostics
+		},
+		"plan": func(server tfprotov6.ProviderServer, schemaResp *tfprotov6.GetProviderSchemaResponse) []*tfprotov6.Diagnostic {
+			stateType := schemaResp.ResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range stateType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+
+			config, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			priorState, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+				TypeName:         "testlagger_lag",
+				PriorState:       &priorState,
+				ProposedNewState: &config,
+				Config:           &config,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return resp.Diagnostics
+		},
+		"open": func(server tfprotov6.ProviderServer, schemaResp *tfprotov6.GetProviderSchemaResponse) []*tfprotov6.Diagnostic {
+			configType := schemaResp.EphemeralResourceSchemas["testlagger_lag"].ValueType().(tftypes.Object)
+
+			attributes := map[string]tftypes.Value{}
+			for name, attributeType := range configType.AttributeTypes {
+				attributes[name] = tftypes.NewValue(attributeType, nil)
+			}
+
+			attributes["input"] = tftypes.NewValue(tftypes.String, "one")
+
+			config, err := tfprotov6.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := server.OpenEphemeralResource(context.Background(), &tfprotov6.OpenEphemeralResourceRequest{TypeName: "testlagger_lag", Config: &config})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return resp.Diagnostics
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			server, schemaResp := testConfiguredProviderServer(t, map[string]tftypes.Value{
+				"requests_per_second": tftypes.NewValue(tftypes.Number, 0.01),
+				"burst":               tftypes.NewValue(tftypes.Number, 1),
+				"throttle_mode":       tftypes.NewValue(tftypes.String, ThrottleModeRetry),
+				"max_retries":         tftypes.NewValue(tftypes.Number, 0),
+			})
+
+			for _, diagnostic := range call(server, schemaResp) {
+				t.Fatalf("unexpected diagnostic %s: %s", diagnostic.Summary, diagnostic.Detail)
+			}
+
+			if diagnostics := call(server, schemaResp); len(diagnostics) != 1 || diagnostics[0].Summary != "Rate Limit Exceeded" {
+				t.Errorf("expected the second call to exceed the rate limit, got %v", diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	validateDelayDescription   = "Amount of time in milliseconds to delay before configuration validation returns. Validation happens before the provider is configured, so the delay is not journaled or counted by `testlagger_concurrency`"
+	validateWarningDescription = "Warning diagnostic to add once the validate delay has elapsed"
+)
+
+// validationCalls counts the validation calls made to the provider process.
+// Validation happens before the provider is configured, so unlike the other
+// lag points there is no client to count them.
+var validationCalls callCounter
+
+// validationDelay is a config validator that sleeps for the validate_delay
+// of the configuration, or its "validate" delay distribution. It runs before
+// ValidateConfig, which injects the configured warning and error.
+type validationDelay struct {
+	// name and operation describe the lag point, for example "Resource Lag
+	// Validate" and "resource_validate".
+	name      string
+	operation string
+
+	// key identifies the lag point when sampling delays, for example
+	// "resource/validate".
+	key string
+
+	// lagPoints are the lag points allowed in delay_distributions.
+	lagPoints []string
+
+	// hasInput is set when the configuration has an input attribute.
+	hasInput bool
+}
+
+var _ resource.ConfigValidator = validationDelay{}
+var _ datasource.ConfigValidator = validationDelay{}
+var _ provider.ConfigValidator = validationDelay{}
+
+func (v validationDelay) Description(ctx context.Context) string {
+	return "Delays validation by validate_delay milliseconds"
+}
+
+func (v validationDelay) MarkdownDescription(ctx context.Context) string {
+	return "Delays validation by `validate_delay` milliseconds"
+}
+
+func (v validationDelay) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(v.validate(ctx, req.Config)...)
+}
+
+func (v validationDelay) ValidateDataSource(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(v.validate(ctx, req.Config)...)
+}
+
+func (v validationDelay) ValidateProvider(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	resp.Diagnostics.Append(v.validate(ctx, req.Config)...)
+}
+
+func (v validationDelay) validate(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var validateDelay types.Int64
+	var distributions types.Map
+
+	diags.Append(config.GetAttribute(ctx, path.Root("validate_delay"), &validateDelay)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("delay_distributions"), &distributions)...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	input := validationInput(ctx, config, v.hasInput)
+
+	// Distributions that depend on values that are not known yet are
+	// checked again when they are used.
+	if value, err := distributions.ToTerraformValue(ctx); err != nil || !value.IsFullyKnown() {
+		distributions = types.MapNull(delayDistributionObjectType)
+	}
+
+	specs, specDiags := delaySpecs(ctx, distributions, v.lagPoints...)
+	diags.Append(specDiags...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	delay := resolveDelay(specs, "validate", validateDelay).Sample(v.key + "/" + input)
+
+	// Client checks the configuration
+	diags.Append(sleep(ctx, nil, lagPoint{Name: v.name, Operation: v.operation, InstanceId: uuid.New().String(), Input: input}, delay)...)
+
+	return diags
+}
+
+// validationInput returns the input of the configuration, or an empty string
+// when it has none or it is not known yet.
+func validationInput(ctx context.Context, config tfsdk.Config, hasInput bool) string {
+	if !hasInput {
+		return ""
+	}
+
+	var input types.String
+
+	if diags := config.GetAttribute(ctx, path.Root("input"), &input); diags.HasError() {
+		return ""
+	}
+
+	return input.ValueString()
+}
+
+// injectValidationDiagnostics adds the validate_warning of the configuration
+// and, when its validate_error fires, an error diagnostic.
+func injectValidationDiagnostics(ctx context.Context, config tfsdk.Config, key string, hasInput bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var warning types.String
+	var fault types.Object
+
+	diags.Append(config.GetAttribute(ctx, path.Root("validate_warning"), &warning)...)
+	diags.Append(config.GetAttribute(ctx, path.Root("validate_error"), &fault)...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	if !warning.IsNull() && !warning.IsUnknown() {
+		diags.AddWarning(
+			"Injected Validate Warning",
+			warning.ValueString(),
+		)
+	}
+
+	call := validationCalls.Count(key)
+
+	diags.Append(injectFault(ctx, "Validate", fault, call, key+"/"+validationInput(ctx, config, hasInput))...)
+
+	return diags
+}
@@ -0,0 +1,113 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testLagResourceValidationConfig returns a lag resource configuration with
+// the given attributes set and every other attribute null.
+func testLagResourceValidationConfig(t *testing.T, values map[string]tftypes.Value) tfsdk.Config {
+	t.Helper()
+
+	var schemaResp resource.SchemaResponse
+	NewLagResource().Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+
+	objectType := schemaResp.Schema.Type().TerraformType(context.Background()).(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := values[name]; ok {
+			attributes[name] = value
+		} else {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+
+	return tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, attributes)}
+}
+
+func TestValidationDelay(t *testing.T) {
+	validator := validationDelay{name: "Resource Lag Validate", operation: "resource_validate", key: "resource/validate", lagPoints: lagResourceLagPoints, hasInput: true}
+
+	config := testLagResourceValidationConfig(t, map[string]tftypes.Value{
+		"validate_delay": tftypes.NewValue(tftypes.Number, 50),
+		"input":          tftypes.NewValue(tftypes.String, "hello"),
+	})
+
+	start := time.Now()
+
+	if diags := validator.validate(context.Background(), config); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected validation to take at least 50ms, took %s", elapsed)
+	}
+}
+
+func TestValidationDelay_Unknown(t *testing.T) {
+	validator := validationDelay{name: "Resource Lag Validate", operation: "resource_validate", key: "resource/validate", lagPoints: lagResourceLagPoints, hasInput: true}
+
+	distributionType := delayDistributionObjectType.TerraformType(context.Background()).(tftypes.Object)
+	distribution := map[string]tftypes.Value{}
+	for name, attributeType := range distributionType.AttributeTypes {
+		distribution[name] = tftypes.NewValue(attributeType, nil)
+	}
+	distribution["distribution"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+
+	config := testLagResourceValidationConfig(t, map[string]tftypes.Value{
+		"validate_delay": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		"delay_distributions": tftypes.NewValue(tftypes.Map{ElementType: distributionType}, map[string]tftypes.Value{
+			"validate": tftypes.NewValue(distributionType, distribution),
+		}),
+		"input": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+	})
+
+	start := time.Now()
+
+	if diags := validator.validate(context.Background(), config); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("expected unknown delays to be skipped, took %s", elapsed)
+	}
+}
+
+func TestInjectValidationDiagnostics(t *testing.T) {
+	faultType := faultObjectType.TerraformType(context.Background()).(tftypes.Object)
+
+	config := testLagResourceValidationConfig(t, map[string]tftypes.Value{
+		"validate_warning": tftypes.NewValue(tftypes.String, "careful"),
+		"validate_error": tftypes.NewValue(faultType, map[string]tftypes.Value{
+			"message":     tftypes.NewValue(tftypes.String, "boom"),
+			"probability": tftypes.NewValue(tftypes.Number, nil),
+			"on_call":     tftypes.NewValue(tftypes.Number, 2),
+			"seed":        tftypes.NewValue(tftypes.Number, nil),
+		}),
+		"input": tftypes.NewValue(tftypes.String, "hello"),
+	})
+
+	key := "test/" + t.Name()
+
+	first := injectValidationDiagnostics(context.Background(), config, key, true)
+	if first.HasError() || first.WarningsCount() != 1 {
+		t.Fatalf("expected a single warning on the first call, got %v", first)
+	}
+
+	second := injectValidationDiagnostics(context.Background(), config, key, true)
+	if second.ErrorsCount() != 1 || second.WarningsCount() != 1 {
+		t.Fatalf("expected a warning and an error on the second call, got %v", second)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// criticalPathTolerance is how much a span may appear to start before its
+// predecessor finished and still be considered to depend on it. It absorbs
+// timestamp rounding and the time Terraform takes between operations.
+const criticalPathTolerance = 5 * time.Millisecond
+
+// Gap is a period during which no lag point was in flight.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the gap.
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// OperationSummary aggregates the spans of a single operation.
+type OperationSummary struct {
+	Operation       string
+	Count           int
+	Cancelled       int
+	Total           time.Duration
+	Max             time.Duration
+	PeakConcurrency int
+}
+
+// Mean returns the average duration of the operation's spans.
+func (o OperationSummary) Mean() time.Duration {
+	if o.Count == 0 {
+		return 0
+	}
+
+	return o.Total / time.Duration(o.Count)
+}
+
+// Analysis is the result of analysing a set of spans.
+type Analysis struct {
+	// Spans are sorted by start time.
+	Spans []Span
+
+	// Lanes holds, for each span, the row it is drawn on so that
+	// overlapping spans never share a row.
+	Lanes     []int
+	LaneCount int
+
+	Start    time.Time
+	End      time.Time
+	WallTime time.Duration
+
+	// BusyTime is the time during which at least one lag point was in
+	// flight.
+	BusyTime time.Duration
+
+	PeakConcurrency int
+	Operations      []OperationSummary
+
+	// CriticalPath holds indexes into Spans of the longest chain of spans
+	// that ran one after another up to the end of the run. The log does not
+	// record dependencies, so the chain is inferred by repeatedly picking the
+	// span that finished last before the current one started.
+	CriticalPath         []int
+	CriticalPathDuration time.Duration
+
+	IdleGaps []Gap
+	IdleTime time.Duration
+}
+
+// Analyze computes wall time, idle gaps, concurrency and the critical path
+// of the given spans.
+func Analyze(spans []Span) *Analysis {
+	a := &Analysis{
+		Spans: append([]Span(nil), spans...),
+	}
+
+	sort.SliceStable(a.Spans, func(i, j int) bool {
+		return a.Spans[i].Start.Before(a.Spans[j].Start)
+	})
+
+	if len(a.Spans) == 0 {
+		return a
+	}
+
+	a.Start = a.Spans[0].Start
+	a.End = a.Spans[0].End
+
+	for _, span := range a.Spans {
+		if span.End.After(a.End) {
+			a.End = span.End
+		}
+	}
+
+	a.WallTime = a.End.Sub(a.Start)
+
+	a.analyzeGaps()
+	a.analyzeConcurrency()
+	a.analyzeLanes()
+	a.analyzeCriticalPath()
+
+	return a
+}
+
+func (a *Analysis) analyzeGaps() {
+	busyUntil := a.Start
+
+	for _, span := range a.Spans {
+		if span.Start.After(busyUntil) {
+			gap := Gap{Start: busyUntil, End: span.Start}
+
+			a.IdleGaps = append(a.IdleGaps, gap)
+			a.IdleTime += gap.Duration()
+		}
+
+		if span.End.After(busyUntil) {
+			busyUntil = span.End
+		}
+	}
+
+	a.BusyTime = a.WallTime - a.IdleTime
+}
+
+// peakConcurrency returns the highest number of the spans that overlap at
+// any instant. A span that ends at the same instant another starts does not
+// overlap it.
+func peakConcurrency(spans []Span) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+
+	edges := make([]edge, 0, len(spans)*2)
+	for _, span := range spans {
+		edges = append(edges, edge{span.Start, 1}, edge{span.End, -1})
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+
+		return edges[i].at.Before(edges[j].at)
+	})
+
+	current, peak := 0, 0
+	for _, e := range edges {
+		current += e.delta
+		peak = max(peak, current)
+	}
+
+	return peak
+}
+
+func (a *Analysis) analyzeConcurrency() {
+	a.PeakConcurrency = peakConcurrency(a.Spans)
+
+	byOperation := map[string][]Span{}
+	for _, span := range a.Spans {
+		byOperation[span.Operation] = append(byOperation[span.Operation], span)
+	}
+
+	for operation, spans := range byOperation {
+		summary := OperationSummary{
+			Operation:       operation,
+			Count:           len(spans),
+			PeakConcurrency: peakConcurrency(spans),
+		}
+
+		for _, span := range spans {
+			summary.Total += span.Duration()
+			summary.Max = max(summary.Max, span.Duration())
+
+			if span.Cancelled {
+				summary.Cancelled++
+			}
+		}
+
+		a.Operations = append(a.Operations, summary)
+	}
+
+	sort.Slice(a.Operations, func(i, j int) bool {
+		if a.Operations[i].Total == a.Operations[j].Total {
+			return a.Operations[i].Operation < a.Operations[j].Operation
+		}
+
+		return a.Operations[i].Total > a.Operations[j].Total
+	})
+}
+
+func (a *Analysis) analyzeLanes() {
+	a.Lanes = make([]int, len(a.Spans))
+
+	var laneEnds []time.Time
+
+	for i, span := range a.Spans {
+		lane := -1
+
+		for l, end := range laneEnds {
+			if !end.After(span.Start) {
+				lane = l
+				break
+			}
+		}
+
+		if lane == -1 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, time.Time{})
+		}
+
+		laneEnds[lane] = span.End
+		a.Lanes[i] = lane
+	}
+
+	a.LaneCount = len(laneEnds)
+}
+
+func (a *Analysis) analyzeCriticalPath() {
+	// longer reports whether span i is a better choice than span j when both
+	// finished at the same time.
+	longer := func(i, j int) bool {
+		return a.Spans[i].Duration() > a.Spans[j].Duration()
+	}
+
+	current := -1
+	for i, span := range a.Spans {
+		if current == -1 || span.End.After(a.Spans[current].End) || (span.End.Equal(a.Spans[current].End) && longer(i, current)) {
+			current = i
+		}
+	}
+
+	var path []int
+
+	for current != -1 {
+		path = append(path, current)
+		a.CriticalPathDuration += a.Spans[current].Duration()
+
+		deadline := a.Spans[current].Start.Add(criticalPathTolerance)
+		previous := -1
+
+		for i, span := range a.Spans {
+			// Spans are sorted by start, and a predecessor must start
+			// before the current span.
+			if !span.Start.Before(a.Spans[current].Start) {
+				break
+			}
+
+			if span.End.After(deadline) {
+				continue
+			}
+
+			if previous == -1 || span.End.After(a.Spans[previous].End) || (span.End.Equal(a.Spans[previous].End) && longer(i, previous)) {
+				previous = i
+			}
+		}
+
+		current = previous
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	a.CriticalPath = path
+}
+
+// OnCriticalPath returns a set of the span indexes on the critical path.
+func (a *Analysis) OnCriticalPath() map[int]bool {
+	onPath := make(map[int]bool, len(a.CriticalPath))
+	for _, i := range a.CriticalPath {
+		onPath[i] = true
+	}
+
+	return onPath
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// chromeTrace is the JSON object format understood by chrome://tracing and
+// Perfetto.
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+// chromeTraceEvent is a complete ("X") event. Timestamps and durations are
+// in microseconds.
+type chromeTraceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"`
+	Duration  int64             `json:"dur"`
+	Pid       int               `json:"pid"`
+	Tid       int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the spans as a Chrome trace event file. Each lane
+// of the Gantt chart becomes a thread so that overlapping lag points are
+// shown side by side.
+func WriteChromeTrace(w io.Writer, a *Analysis) error {
+	trace := chromeTrace{
+		TraceEvents:     make([]chromeTraceEvent, 0, len(a.Spans)),
+		DisplayTimeUnit: "ms",
+	}
+
+	onPath := a.OnCriticalPath()
+
+	for i, span := range a.Spans {
+		args := map[string]string{
+			"ids":      span.Ids,
+			"delay":    span.Delay.String(),
+			"duration": span.Duration().String(),
+		}
+
+		if span.Input != "" {
+			args["input"] = span.Input
+		}
+
+		if span.ResourceType != "" {
+			args["resource_type"] = span.ResourceType
+		}
+
+		if span.Rpc != "" {
+			args["rpc"] = span.Rpc
+		}
+
+		if span.Cancelled {
+			args["cancelled"] = "true"
+		}
+
+		if onPath[i] {
+			args["critical_path"] = "true"
+		}
+
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name:      span.Label(),
+			Category:  span.Operation,
+			Phase:     "X",
+			Timestamp: span.Start.Sub(a.Start).Microseconds(),
+			Duration:  span.Duration().Microseconds(),
+			Pid:       1,
+			Tid:       a.Lanes[i] + 1,
+			Args:      args,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(trace)
+}
@@ -0,0 +1,192 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	ganttWidth      = 1200
+	ganttLabelWidth = 60
+	ganttRowHeight  = 18
+	ganttAxisHeight = 24
+	ganttRowSpacing = 2
+)
+
+// ganttPalette colours operations in the order they are first seen.
+var ganttPalette = []string{
+	"#4e79a7", "#f28e2b", "#59a14f", "#b07aa1", "#76b7b2",
+	"#edc948", "#ff9da7", "#9c755f", "#bab0ac", "#e15759",
+}
+
+type ganttBar struct {
+	X, Y, Width float64
+	Colour      string
+	Critical    bool
+	Cancelled   bool
+	Title       string
+}
+
+type ganttLane struct {
+	Y     float64
+	Label string
+}
+
+type ganttTick struct {
+	X     float64
+	Label string
+}
+
+type ganttLegend struct {
+	Operation string
+	Colour    string
+}
+
+type ganttData struct {
+	Width, Height int
+	AxisHeight    int
+	LanesHeight   int
+	RowHeight     int
+	Lanes         []ganttLane
+	Bars          []ganttBar
+	Gaps          []ganttBar
+	Ticks         []ganttTick
+	Legend        []ganttLegend
+	Summary       string
+}
+
+var ganttTemplate = template.Must(template.New("gantt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>testlagger report</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.legend span { display: inline-block; margin-right: 1em; }
+.legend i { display: inline-block; width: 1em; height: 1em; margin-right: 0.3em; vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>testlagger report</h1>
+<p class="legend">{{range .Legend}}<span><i style="background: {{.Colour}}"></i>{{.Operation}}</span>{{end}}<span><i style="border: 2px solid #000; box-sizing: border-box"></i>critical path</span><span><i style="background: #fdd"></i>idle</span></p>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" font-size="11">
+{{- range .Gaps}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{$.LanesHeight}}" fill="#fdd"><title>{{.Title}}</title></rect>
+{{- end}}
+{{- range .Ticks}}
+<line x1="{{.X}}" y1="{{$.AxisHeight}}" x2="{{.X}}" y2="{{$.Height}}" stroke="#ddd"/>
+<text x="{{.X}}" y="{{$.AxisHeight}}" dy="-6" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+{{- range .Lanes}}
+<text x="4" y="{{.Y}}" dy="13">{{.Label}}</text>
+{{- end}}
+{{- range .Bars}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{$.RowHeight}}" fill="{{.Colour}}"{{if .Cancelled}} fill-opacity="0.4"{{end}}{{if .Critical}} stroke="#000" stroke-width="2"{{end}}><title>{{.Title}}</title></rect>
+{{- end}}
+</svg>
+<pre>{{.Summary}}</pre>
+</body>
+</html>
+`))
+
+// laneY returns the y position of the top of a lane in the Gantt chart.
+func (a *Analysis) laneY(lane int) float64 {
+	return float64(ganttAxisHeight + lane*(ganttRowHeight+ganttRowSpacing))
+}
+
+// WriteHTML writes a self-contained HTML page holding an SVG Gantt chart of
+// the spans followed by the text summary.
+func WriteHTML(w io.Writer, a *Analysis) error {
+	var summary bytes.Buffer
+	if err := WriteText(&summary, a); err != nil {
+		return err
+	}
+
+	data := ganttData{
+		AxisHeight:  ganttAxisHeight,
+		LanesHeight: a.LaneCount * (ganttRowHeight + ganttRowSpacing),
+		RowHeight:   ganttRowHeight,
+		Summary:     summary.String(),
+	}
+
+	// Leave room for the label of the last tick.
+	data.Width = ganttLabelWidth + ganttWidth + 30
+	data.Height = ganttAxisHeight + data.LanesHeight
+
+	scale := 0.0
+	if a.WallTime > 0 {
+		scale = float64(ganttWidth) / float64(a.WallTime)
+	}
+
+	for lane := 0; lane < a.LaneCount; lane++ {
+		data.Lanes = append(data.Lanes, ganttLane{
+			Y:     a.laneY(lane),
+			Label: fmt.Sprintf("lane %d", lane+1),
+		})
+	}
+
+	colours := map[string]string{}
+	for _, span := range a.Spans {
+		if _, ok := colours[span.Operation]; !ok {
+			colours[span.Operation] = ganttPalette[len(colours)%len(ganttPalette)]
+		}
+	}
+
+	for operation, colour := range colours {
+		data.Legend = append(data.Legend, ganttLegend{Operation: operation, Colour: colour})
+	}
+
+	sort.Slice(data.Legend, func(i, j int) bool {
+		return data.Legend[i].Operation < data.Legend[j].Operation
+	})
+
+	onPath := a.OnCriticalPath()
+
+	for i, span := range a.Spans {
+		title := fmt.Sprintf("%s %s\n%s for %s", span.Label(), span.Ids, a.formatOffset(span.Start), formatDuration(span.Duration()))
+		if span.Cancelled {
+			title += " (cancelled)"
+		}
+
+		data.Bars = append(data.Bars, ganttBar{
+			X:         float64(ganttLabelWidth) + float64(span.Start.Sub(a.Start))*scale,
+			Y:         a.laneY(a.Lanes[i]),
+			Width:     max(float64(span.Duration())*scale, 1),
+			Colour:    colours[span.Operation],
+			Critical:  onPath[i],
+			Cancelled: span.Cancelled,
+			Title:     title,
+		})
+	}
+
+	for _, gap := range a.IdleGaps {
+		data.Gaps = append(data.Gaps, ganttBar{
+			X:     float64(ganttLabelWidth) + float64(gap.Start.Sub(a.Start))*scale,
+			Y:     float64(ganttAxisHeight),
+			Width: max(float64(gap.Duration())*scale, 1),
+			Title: fmt.Sprintf("idle %s for %s", a.formatOffset(gap.Start), formatDuration(gap.Duration())),
+		})
+	}
+
+	const tickCount = 10
+	for tick := 0; tick <= tickCount; tick++ {
+		at := a.WallTime * time.Duration(tick) / tickCount
+
+		data.Ticks = append(data.Ticks, ganttTick{
+			X:     float64(ganttLabelWidth) + float64(at)*scale,
+			Label: formatDuration(at),
+		})
+	}
+
+	return ganttTemplate.Execute(w, data)
+}
@@ -0,0 +1,387 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Span is a single lag point, from the moment it started sleeping until it
+// finished or was cancelled.
+type Span struct {
+	// Name is the lag point name from the trace message, for example
+	// "Resource Lag Create".
+	Name string
+
+	// Operation is the machine readable lag point, for example
+	// "resource_create".
+	Operation string
+
+	// Ids are the client and instance ids of the lag point.
+	Ids string
+
+	// Input is the input being echoed, when known.
+	Input string
+
+	// ResourceType and Rpc are taken from the log line context, when known.
+	ResourceType string
+	Rpc          string
+
+	Delay     time.Duration
+	Start     time.Time
+	End       time.Time
+	Cancelled bool
+}
+
+// Duration returns how long the span lasted.
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Label returns a short description of the span for use in reports.
+func (s Span) Label() string {
+	var parts []string
+
+	if s.ResourceType != "" {
+		parts = append(parts, s.ResourceType)
+	}
+
+	if s.Input != "" {
+		parts = append(parts, fmt.Sprintf("%q", s.Input))
+	}
+
+	if len(parts) == 0 {
+		return s.Operation
+	}
+
+	return s.Operation + " " + strings.Join(parts, " ")
+}
+
+var (
+	// sleepMessage matches the trace messages written by the provider, for
+	// example "Resource Lag Create (<client>/<instance>): Start sleeping for
+	// 1000 milliseconds...". Older versions of the provider said seconds
+	// while sleeping for milliseconds.
+	sleepMessage = regexp.MustCompile(`([A-Za-z][A-Za-z ]*?) \(([^)]*)\): (Start sleeping for|Finished sleeping for|Cancelled sleeping after) (\d+)(?: of (\d+))? (?:milliseconds|seconds)`)
+
+	// logTimestamp matches the timestamp at the start of a TF_LOG line.
+	logTimestamp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2}))`)
+
+	logResourceType = regexp.MustCompile(`tf_resource_type=(\S+)`)
+	logRpc          = regexp.MustCompile(`tf_rpc=(\S+)`)
+)
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	var err error
+
+	for _, layout := range timestampLayouts {
+		var t time.Time
+
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// operationName converts a lag point name such as "Resource Lag Create" into
+// an operation such as "resource_create". Time spent throttled is named as in
+// the journal, whichever call was throttled.
+func operationName(name string) string {
+	switch name {
+	case "Lag Function":
+		return "function_lag"
+	case "Lag Dynamic Function":
+		return "function_lag_dynamic"
+	}
+
+	switch {
+	case strings.HasSuffix(name, " Throttled"):
+		return "throttle_wait"
+	case strings.HasSuffix(name, " Retry"):
+		return "throttle_retry"
+	}
+
+	var words []string
+	for _, word := range strings.Fields(name) {
+		if word == "Lag" {
+			continue
+		}
+		words = append(words, strings.ToLower(word))
+	}
+
+	return strings.Join(words, "_")
+}
+
+// event is a start, finish or cancellation of a lag point.
+type event struct {
+	kind         string
+	name         string
+	operation    string
+	ids          string
+	input        string
+	resourceType string
+	rpc          string
+	delay        time.Duration
+	at           time.Time
+}
+
+const (
+	eventStart     = "start"
+	eventFinish    = "finish"
+	eventCancelled = "cancelled"
+)
+
+// Parse reads lag point spans from either a TF_LOG file, in text or JSON
+// format, or a journal written by the provider's journal_path option. Lines
+// that are not lag point events are ignored. Spans that never finished are
+// dropped.
+func Parse(r io.Reader) ([]Span, error) {
+	var events []event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	// previous is true when the last log line held an event.
+	previous := false
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var e *event
+		var err error
+
+		if strings.HasPrefix(line, "{") {
+			e, err = parseJSONLine(line)
+		} else if !logTimestamp.MatchString(line) {
+			// The trace messages end with a newline, so Terraform writes
+			// their fields on the following line.
+			if previous {
+				addLogContext(&events[len(events)-1], line)
+			}
+
+			continue
+		} else {
+			e, err = parseTextLine(line)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		previous = e != nil
+		if previous {
+			events = append(events, *e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pairEvents(events), nil
+}
+
+func parseTextLine(line string) (*event, error) {
+	match := sleepMessage.FindStringSubmatch(line)
+	if match == nil {
+		return nil, nil
+	}
+
+	timestamp := logTimestamp.FindStringSubmatch(line)
+	if timestamp == nil {
+		return nil, fmt.Errorf("lag point message has no timestamp")
+	}
+
+	at, err := parseTimestamp(timestamp[1])
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := messageEvent(match, at)
+	if err != nil {
+		return nil, err
+	}
+
+	addLogContext(e, line)
+
+	return e, nil
+}
+
+// addLogContext copies the resource type and RPC from the fields of a log
+// line to the event.
+func addLogContext(e *event, line string) {
+	if m := logResourceType.FindStringSubmatch(line); m != nil && e.resourceType == "" {
+		e.resourceType = m[1]
+	}
+
+	if m := logRpc.FindStringSubmatch(line); m != nil && e.rpc == "" {
+		e.rpc = m[1]
+	}
+}
+
+func messageEvent(match []string, at time.Time) (*event, error) {
+	e := &event{
+		name:      strings.TrimSpace(match[1]),
+		ids:       match[2],
+		at:        at,
+		operation: operationName(strings.TrimSpace(match[1])),
+	}
+
+	delay := match[4]
+
+	switch match[3] {
+	case "Start sleeping for":
+		e.kind = eventStart
+	case "Finished sleeping for":
+		e.kind = eventFinish
+	default:
+		e.kind = eventCancelled
+		delay = match[5]
+	}
+
+	milliseconds, err := strconv.ParseInt(delay, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	e.delay = time.Duration(milliseconds) * time.Millisecond
+
+	return e, nil
+}
+
+// jsonLine holds the fields of both JSON formatted TF_LOG lines and journal
+// lines that are needed to build spans.
+type jsonLine struct {
+	// TF_LOG=JSON
+	Message      string `json:"@message"`
+	Timestamp    string `json:"@timestamp"`
+	ResourceType string `json:"tf_resource_type"`
+	Rpc          string `json:"tf_rpc"`
+
+	// journal_path
+	Event      string `json:"event"`
+	Operation  string `json:"operation"`
+	ClientId   string `json:"client_id"`
+	InstanceId string `json:"instance_id"`
+	Input      string `json:"input"`
+	DelayMs    int64  `json:"delay_ms"`
+	Time       string `json:"timestamp"`
+}
+
+func parseJSONLine(line string) (*event, error) {
+	var l jsonLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil {
+		return nil, err
+	}
+
+	if l.Event != "" {
+		at, err := parseTimestamp(l.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		return &event{
+			kind:      l.Event,
+			name:      l.Operation,
+			operation: l.Operation,
+			ids:       l.ClientId + "/" + l.InstanceId,
+			input:     l.Input,
+			delay:     time.Duration(l.DelayMs) * time.Millisecond,
+			at:        at,
+		}, nil
+	}
+
+	match := sleepMessage.FindStringSubmatch(l.Message)
+	if match == nil {
+		return nil, nil
+	}
+
+	at, err := parseTimestamp(l.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := messageEvent(match, at)
+	if err != nil {
+		return nil, err
+	}
+
+	e.resourceType = l.ResourceType
+	e.rpc = l.Rpc
+
+	return e, nil
+}
+
+// pairEvents matches each start event with the next finish or cancellation of
+// the same lag point.
+func pairEvents(events []event) []Span {
+	type key struct{ name, ids string }
+
+	open := map[key][]event{}
+
+	var spans []Span
+
+	for _, e := range events {
+		k := key{e.name, e.ids}
+
+		if e.kind == eventStart {
+			open[k] = append(open[k], e)
+			continue
+		}
+
+		starts := open[k]
+		if len(starts) == 0 {
+			continue
+		}
+
+		start := starts[0]
+		open[k] = starts[1:]
+
+		span := Span{
+			Name:         start.name,
+			Operation:    start.operation,
+			Ids:          start.ids,
+			Input:        start.input,
+			ResourceType: start.resourceType,
+			Rpc:          start.rpc,
+			Delay:        start.delay,
+			Start:        start.at,
+			End:          e.at,
+			Cancelled:    e.kind == eventCancelled,
+		}
+
+		// Timestamps in text logs only have millisecond precision, so fall
+		// back to the requested delay when the two events share a timestamp.
+		if !span.End.After(span.Start) {
+			span.End = span.Start.Add(span.Delay)
+		}
+
+		spans = append(spans, span)
+	}
+
+	return spans
+}
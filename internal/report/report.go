@@ -0,0 +1,107 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package report turns the lag point trace messages written by the provider
+// into a timeline of the run, showing where the time went.
+package report
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Output formats.
+const (
+	FormatText   = "text"
+	FormatHTML   = "html"
+	FormatChrome = "chrome"
+)
+
+// Write writes the analysis in the given format.
+func Write(w io.Writer, format string, a *Analysis) error {
+	switch format {
+	case FormatText:
+		return WriteText(w, a)
+	case FormatHTML:
+		return WriteHTML(w, a)
+	case FormatChrome:
+		return WriteChromeTrace(w, a)
+	default:
+		return fmt.Errorf("unknown format %q, expected one of %q, %q or %q", format, FormatText, FormatHTML, FormatChrome)
+	}
+}
+
+// Run implements the report subcommand. The log file is read from the first
+// argument, falling back to TF_LOG_PATH; "-" reads from stdin.
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: terraform-provider-testlagger report [options] [TF_LOG_PATH or journal]\n\n")
+		fmt.Fprintf(stderr, "Reports the critical path, wall time, idle gaps and concurrency of the lag points in a log.\n\n")
+		flags.PrintDefaults()
+	}
+
+	var format, output string
+
+	flags.StringVar(&format, "format", FormatText, "output format: text, html (SVG Gantt chart) or chrome (trace event JSON for chrome://tracing)")
+	flags.StringVar(&output, "output", "", "file to write the report to instead of stdout")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("expected at most one log file")
+	}
+
+	input := os.Getenv("TF_LOG_PATH")
+	if flags.NArg() == 1 {
+		input = flags.Arg(0)
+	}
+
+	if input == "" {
+		flags.Usage()
+		return errors.New("no log file given and TF_LOG_PATH is not set")
+	}
+
+	var r io.Reader = stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	spans, err := Parse(r)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", input, err)
+	}
+
+	analysis := Analyze(spans)
+
+	if output == "" {
+		return Write(stdout, format, analysis)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(file, format, analysis); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
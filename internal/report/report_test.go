@@ -0,0 +1,248 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLog is a trimmed TF_LOG=TRACE file. Configure runs first, two creates
+// run in parallel, then a third create that depends on the slower of them
+// runs after an idle gap.
+const testLog = `2024-05-01T10:00:00.000Z [INFO]  Terraform version: 1.8.0
+2024-05-01T10:00:00.000Z [TRACE] provider.terraform-provider-testlagger: Provider Configure (c1): Start sleeping for 100 milliseconds...
+: @module=testlagger tf_provider_addr=registry.opentofu.org/opentofu/testlagger tf_rpc=ConfigureProvider timestamp=2024-05-01T10:00:00.000Z
+2024-05-01T10:00:00.100Z [TRACE] provider.terraform-provider-testlagger: Provider Configure (c1): Finished sleeping for 100 milliseconds...
+: @module=testlagger tf_rpc=ConfigureProvider
+2024-05-01T10:00:00.100Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/a): Start sleeping for 1000 milliseconds...
+: @module=testlagger tf_resource_type=testlagger_lag tf_rpc=ApplyResourceChange
+2024-05-01T10:00:00.100Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/b): Start sleeping for 500 milliseconds...
+: @module=testlagger tf_resource_type=testlagger_lag tf_rpc=ApplyResourceChange
+2024-05-01T10:00:00.600Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/b): Finished sleeping for 500 milliseconds...
+2024-05-01T10:00:01.100Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/a): Finished sleeping for 1000 milliseconds...
+2024-05-01T10:00:01.100Z [DEBUG] provider.terraform-provider-testlagger: unrelated message
+: tf_resource_type=testlagger_other
+2024-05-01T10:00:01.300Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/c): Start sleeping for 200 milliseconds...
+: @module=testlagger tf_resource_type=testlagger_lag tf_rpc=ApplyResourceChange
+2024-05-01T10:00:01.450Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create (c1/c): Cancelled sleeping after 150 of 200 milliseconds...
+`
+
+func TestParseTextLog(t *testing.T) {
+	spans, err := Parse(strings.NewReader(testLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d: %+v", len(spans), spans)
+	}
+
+	configure := spans[0]
+	if configure.Operation != "provider_configure" || configure.Ids != "c1" || configure.Rpc != "ConfigureProvider" || configure.Duration() != 100*time.Millisecond {
+		t.Errorf("unexpected configure span: %+v", configure)
+	}
+
+	create := spans[1]
+	if create.Operation != "resource_create" || create.Ids != "c1/b" || create.ResourceType != "testlagger_lag" || create.Delay != 500*time.Millisecond {
+		t.Errorf("unexpected create span: %+v", create)
+	}
+
+	cancelled := spans[3]
+	if !cancelled.Cancelled || cancelled.Delay != 200*time.Millisecond || cancelled.Duration() != 150*time.Millisecond {
+		t.Errorf("unexpected cancelled span: %+v", cancelled)
+	}
+}
+
+func TestParseJSONLog(t *testing.T) {
+	log := `{"@level":"trace","@message":"Datasource Lag Read (c1/d): Start sleeping for 250 milliseconds...\n","@timestamp":"2024-05-01T10:00:00.000000+01:00","tf_resource_type":"testlagger_lag","tf_rpc":"ReadDataSource"}
+{"@level":"info","@message":"not a lag point","@timestamp":"2024-05-01T10:00:00.100000+01:00"}
+{"@level":"trace","@message":"Datasource Lag Read (c1/d): Finished sleeping for 250 milliseconds...\n","@timestamp":"2024-05-01T10:00:00.250000+01:00","tf_resource_type":"testlagger_lag","tf_rpc":"ReadDataSource"}
+`
+
+	spans, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Operation != "datasource_read" || spans[0].Rpc != "ReadDataSource" || spans[0].Duration() != 250*time.Millisecond {
+		t.Errorf("unexpected span: %+v", spans[0])
+	}
+}
+
+func TestParseJournal(t *testing.T) {
+	journal := `{"event":"start","operation":"function_lag","client_id":"c1","instance_id":"f","input":"hello","delay_ms":50,"timestamp":"2024-05-01T10:00:00.000000001Z","monotonic_ns":1,"pid":1}
+{"event":"finish","operation":"function_lag","client_id":"c1","instance_id":"f","input":"hello","delay_ms":50,"elapsed_ms":50,"timestamp":"2024-05-01T10:00:00.050000001Z","monotonic_ns":50000001,"pid":1}
+`
+
+	spans, err := Parse(strings.NewReader(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Operation != "function_lag" || spans[0].Input != "hello" || spans[0].Ids != "c1/f" || spans[0].Duration() != 50*time.Millisecond {
+		t.Errorf("unexpected span: %+v", spans[0])
+	}
+}
+
+func TestParseThrottle(t *testing.T) {
+	log := `2024-05-01T10:00:00.000Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create Throttled (c1/a): Start sleeping for 100 milliseconds...
+2024-05-01T10:00:00.100Z [TRACE] provider.terraform-provider-testlagger: Resource Lag Create Throttled (c1/a): Finished sleeping for 100 milliseconds...
+`
+	journal := `{"event":"start","operation":"throttle_wait","client_id":"c1","instance_id":"a","delay_ms":100,"timestamp":"2024-05-01T10:00:00Z","monotonic_ns":1,"pid":1}
+{"event":"finish","operation":"throttle_wait","client_id":"c1","instance_id":"a","delay_ms":100,"elapsed_ms":100,"timestamp":"2024-05-01T10:00:00.1Z","monotonic_ns":100000001,"pid":1}
+`
+
+	// The log and the journal name the same lag point alike
+	for name, input := range map[string]string{"log": log, "journal": journal} {
+		spans, err := Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(spans) != 1 || spans[0].Operation != "throttle_wait" || spans[0].Duration() != 100*time.Millisecond {
+			t.Errorf("expected one throttle_wait span of 100ms from the %s, got %+v", name, spans)
+		}
+	}
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	_, err := Parse(strings.NewReader("{not json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected an error for line 1, got %v", err)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Provider Configure":        "provider_configure",
+		"Resource Lag Configure":    "resource_configure",
+		"Resource Lag Import State": "resource_import_state",
+		"Datasource Lag Read":       "datasource_read",
+		"Lag Function":              "function_lag",
+		"Lag Dynamic Function":      "function_lag_dynamic",
+		"Resource Lag Create Retry": "throttle_retry",
+	} {
+		if actual := operationName(name); actual != expected {
+			t.Errorf("operationName(%q) = %q, expected %q", name, actual, expected)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	spans, err := Parse(strings.NewReader(testLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := Analyze(spans)
+
+	if a.WallTime != 1450*time.Millisecond {
+		t.Errorf("expected 1.45s of wall time, got %s", a.WallTime)
+	}
+
+	if a.PeakConcurrency != 2 {
+		t.Errorf("expected peak concurrency of 2, got %d", a.PeakConcurrency)
+	}
+
+	if len(a.IdleGaps) != 1 || a.IdleGaps[0].Duration() != 200*time.Millisecond || a.IdleTime != 200*time.Millisecond {
+		t.Errorf("expected a single 200ms idle gap, got %+v", a.IdleGaps)
+	}
+
+	if a.BusyTime != 1250*time.Millisecond {
+		t.Errorf("expected 1.25s of busy time, got %s", a.BusyTime)
+	}
+
+	var path []string
+	for _, i := range a.CriticalPath {
+		path = append(path, a.Spans[i].Ids)
+	}
+
+	if strings.Join(path, ",") != "c1,c1/a,c1/c" {
+		t.Errorf("unexpected critical path %v", path)
+	}
+
+	if a.CriticalPathDuration != 1250*time.Millisecond {
+		t.Errorf("expected a 1.25s critical path, got %s", a.CriticalPathDuration)
+	}
+
+	if a.LaneCount != 2 {
+		t.Errorf("expected 2 lanes, got %d", a.LaneCount)
+	}
+
+	if len(a.Operations) != 2 || a.Operations[0].Operation != "resource_create" || a.Operations[0].Count != 3 || a.Operations[0].Cancelled != 1 || a.Operations[0].PeakConcurrency != 2 {
+		t.Errorf("unexpected operations %+v", a.Operations)
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	a := Analyze(nil)
+
+	for _, format := range []string{FormatText, FormatHTML, FormatChrome} {
+		var out bytes.Buffer
+		if err := Write(&out, format, a); err != nil {
+			t.Errorf("%s: %s", format, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	spans, err := Parse(strings.NewReader(testLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := Analyze(spans)
+
+	var text bytes.Buffer
+	if err := Write(&text, FormatText, a); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"Wall time:", "1.450s", "Peak concurrency:", "resource_create", "Critical path (inferred, 3 lag points", "Idle gaps:"} {
+		if !strings.Contains(text.String(), expected) {
+			t.Errorf("expected text report to contain %q:\n%s", expected, text.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := Write(&html, FormatHTML, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(html.String(), `stroke="#000"`) != 3 {
+		t.Errorf("expected three critical path bars:\n%s", html.String())
+	}
+
+	var chrome bytes.Buffer
+	if err := Write(&chrome, FormatChrome, a); err != nil {
+		t.Fatal(err)
+	}
+
+	var trace chromeTrace
+	if err := json.Unmarshal(chrome.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trace.TraceEvents) != 4 || trace.TraceEvents[2].Timestamp != 100000 || trace.TraceEvents[2].Duration != 1000000 {
+		t.Errorf("unexpected trace events %+v", trace.TraceEvents)
+	}
+
+	if err := Write(&bytes.Buffer{}, "pdf", a); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// formatDuration formats d in seconds with millisecond precision.
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
+
+// formatOffset formats the time between the start of the run and t.
+func (a *Analysis) formatOffset(t time.Time) string {
+	return "+" + formatDuration(t.Sub(a.Start))
+}
+
+// percentOfWallTime returns d as a percentage of the wall time.
+func (a *Analysis) percentOfWallTime(d time.Duration) float64 {
+	if a.WallTime == 0 {
+		return 0
+	}
+
+	return float64(d) / float64(a.WallTime) * 100
+}
+
+// WriteText writes a plain text summary of the analysis.
+func WriteText(w io.Writer, a *Analysis) error {
+	if len(a.Spans) == 0 {
+		_, err := fmt.Fprintln(w, "No lag points found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Wall time:\t%s\t(%s to %s)\n", formatDuration(a.WallTime), a.Start.Format(time.RFC3339Nano), a.End.Format(time.RFC3339Nano))
+	fmt.Fprintf(tw, "Busy time:\t%s\t(%.1f%%)\n", formatDuration(a.BusyTime), a.percentOfWallTime(a.BusyTime))
+	fmt.Fprintf(tw, "Idle time:\t%s\t(%.1f%%, %d gaps)\n", formatDuration(a.IdleTime), a.percentOfWallTime(a.IdleTime), len(a.IdleGaps))
+	fmt.Fprintf(tw, "Lag points:\t%d\t\n", len(a.Spans))
+	fmt.Fprintf(tw, "Peak concurrency:\t%d\t\n", a.PeakConcurrency)
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Operations:")
+
+	fmt.Fprintln(tw, "  OPERATION\tCOUNT\tCANCELLED\tTOTAL\tMEAN\tMAX\tPEAK CONCURRENCY")
+	for _, o := range a.Operations {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\t%s\t%s\t%d\n", o.Operation, o.Count, o.Cancelled, formatDuration(o.Total), formatDuration(o.Mean()), formatDuration(o.Max), o.PeakConcurrency)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Critical path (inferred, %d lag points, %s of lag, %.1f%% of wall time):\n", len(a.CriticalPath), formatDuration(a.CriticalPathDuration), a.percentOfWallTime(a.CriticalPathDuration))
+
+	for _, i := range a.CriticalPath {
+		span := a.Spans[i]
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", a.formatOffset(span.Start), formatDuration(span.Duration()), span.Label(), span.Ids)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(a.IdleGaps) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Idle gaps:")
+
+		for _, gap := range a.IdleGaps {
+			fmt.Fprintf(tw, "  %s\t%s\n", a.formatOffset(gap.Start), formatDuration(gap.Duration()))
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,12 +7,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"log"
+	"os"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 
+	"github.com/opentofu/terraform-provider-testlagger/internal/daemon"
+	"github.com/opentofu/terraform-provider-testlagger/internal/generate"
 	"github.com/opentofu/terraform-provider-testlagger/internal/provider"
+	"github.com/opentofu/terraform-provider-testlagger/internal/report"
 )
 
 // Run "go generate" to format example terraform files and generate the docs for the registry/website
@@ -25,7 +30,37 @@ var (
 	version string = "dev"
 )
 
+// subcommands are run instead of serving the provider when named by the first
+// argument.
+var subcommands = map[string]func(args []string) error{
+	"daemon": func(args []string) error {
+		return daemon.Run(args, os.Stdout, os.Stderr)
+	},
+	"generate": func(args []string) error {
+		return generate.Run(args, os.Stdout, os.Stderr)
+	},
+	"report": func(args []string) error {
+		return report.Run(args, os.Stdin, os.Stdout, os.Stderr)
+	},
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			err := subcommand(os.Args[2:])
+
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+
+			return
+		}
+	}
+
 	var debug bool
 
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
@@ -36,7 +71,12 @@ func main() {
 		Debug:   debug,
 	}
 
-	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+	startup, err := provider.StartupOptionsFromEnvironment(os.Getenv)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	err = providerserver.Serve(context.Background(), provider.NewWithStartupOptions(version, startup), opts)
 
 	if err != nil {
 		log.Fatal(err.Error())
@@ -53,4 +53,3 @@ module "generated-9" {
 module "generated-10" {
   source = "./generated"
 }
-
@@ -14,6 +14,6 @@ data "testlagger_lag" "test" {
 }
 
 variable "enabled" {
-  type = bool
+  type    = bool
   default = false
 }
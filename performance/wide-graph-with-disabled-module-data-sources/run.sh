@@ -1,6 +1,4 @@
-mkdir -p ./generated
-for i in $(seq 1 10); do echo module \"module-$i\" \{ source = \"../submodule\" \}; done > ./generated/main.tf
-
+# Generated by "terraform-provider-testlagger generate", see README.md.
 tofu init
 time tofu plan -out out.tfplan
 time tofu show -json out.tfplan > /dev/null
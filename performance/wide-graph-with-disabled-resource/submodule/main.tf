@@ -71,6 +71,6 @@ resource "testlagger_lag" "iter7" {
 }
 
 variable "enabled" {
-  type = bool
+  type    = bool
   default = false
 }